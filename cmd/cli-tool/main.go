@@ -13,10 +13,20 @@ import (
 
 func main() {
 	var verbose bool
+	var erStyle string
 	flag.BoolVar(&verbose, "verbose", false, "Enable verbose error output")
+	flag.StringVar(&erStyle, "er-style", "list", "ER diagram rendering: list or table")
 	flag.Parse()
 	
-	if err := run(verbose); err != nil {
+	options, err := parseOptions(erStyle)
+	if err != nil {
+		// Flag mistakes are always reported, even without -verbose
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		flag.Usage()
+		os.Exit(1)
+	}
+	
+	if err := run(verbose, options); err != nil {
 		if verbose {
 			log.Printf("Error: %v", err)
 		}
@@ -24,7 +34,15 @@ func main() {
 	}
 }
 
-func run(verbose bool) error {
+func parseOptions(erStyle string) (drawio.Options, error) {
+	style, err := drawio.ParseERStyle(erStyle)
+	if err != nil {
+		return drawio.Options{}, err
+	}
+	return drawio.Options{ERStyle: style}, nil
+}
+
+func run(verbose bool, options drawio.Options) error {
 	input, err := io.ReadAll(os.Stdin)
 	if err != nil {
 		return fmt.Errorf("reading input: %w", err)
@@ -35,7 +53,7 @@ func run(verbose bool) error {
 		return fmt.Errorf("parsing Mermaid diagram: %w", err)
 	}
 	
-	xmlOutput, err := drawio.GenerateDrawIOXML(diagram)
+	xmlOutput, err := drawio.GenerateDrawIOXMLWithOptions(diagram, options)
 	if err != nil {
 		return fmt.Errorf("generating draw.io XML: %w", err)
 	}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"strings"
 	"testing"
+	
+	"mermaid2drawio/internal/drawio"
 )
 
 func TestRun(t *testing.T) {
@@ -14,6 +16,7 @@ func TestRun(t *testing.T) {
 		expected string
 		wantErr  bool
 		verbose  bool
+		options  drawio.Options
 	}{
 		{
 			name:     "simple sequence diagram",
@@ -50,6 +53,14 @@ func TestRun(t *testing.T) {
 			wantErr:  false,
 			verbose:  false,
 		},
+		{
+			name:     "ER diagram as tables",
+			input:    "erDiagram\n    USER {\n        int id PK\n    }",
+			expected: "shape=table;",
+			wantErr:  false,
+			verbose:  false,
+			options:  drawio.Options{ERStyle: drawio.ERTableStyle},
+		},
 		{
 			name:     "verbose mode",
 			input:    "sequenceDiagram\n    A->B: Hello",
@@ -78,7 +89,7 @@ func TestRun(t *testing.T) {
 			}()
 			
 			// Run the function
-			err := run(tt.verbose)
+			err := run(tt.verbose, tt.options)
 			
 			// Close stdout writer and read output
 			wOut.Close()
@@ -104,3 +115,14 @@ func TestRun(t *testing.T) {
 	}
 }
 
+
+func TestParseOptions(t *testing.T) {
+	options, err := parseOptions("table")
+	if err != nil || options.ERStyle != drawio.ERTableStyle {
+		t.Errorf("Expected table style, got %v (%v)", options.ERStyle, err)
+	}
+	
+	if _, err := parseOptions("grid"); err == nil {
+		t.Error("Expected error for unknown ER style")
+	}
+}
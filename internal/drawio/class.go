@@ -0,0 +1,347 @@
+package drawio
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"mermaid2drawio/internal/mermaid"
+)
+
+// Layout constants for class diagrams
+const (
+	ClassWidth                 = 160.0
+	ClassHeaderHeight          = 26.0
+	ClassAnnotatedHeaderHeight = 40.0
+	ClassMemberHeight          = 26.0
+	ClassSeparatorHeight       = 8.0
+	ClassCharWidth             = 7.0
+	ClassRankSpacing           = 80.0
+	ClassSpacing               = 60.0
+	NamespacePadding           = 20.0
+	NamespaceHeader            = 30.0
+)
+
+func GenerateClassDrawIOXML(diagram *mermaid.ClassDiagram) (string, error) {
+	model := createBaseModel()
+
+	cells := createDefaultCells()
+	cellID := 2
+	classCells := make(map[string]string)
+	namespaceCells := make(map[string]string)
+
+	layout := newNestedLayout(diagram.Direction, ClassRankSpacing, ClassSpacing, NamespacePadding, NamespaceHeader)
+	for _, namespace := range diagram.Namespaces {
+		layout.addContainer(namespacePrefix+namespace, "", "", float64(utf8.RuneCountInString(namespace))*ClassCharWidth+2*NamespacePadding)
+	}
+	for _, class := range diagram.Classes {
+		width, height := classSize(class)
+		layout.addNode(class.Name, namespaceParent(class.Namespace), width, height)
+	}
+	for _, relation := range diagram.Relations {
+		// Keep parents and wholes above their children and parts
+		if relation.FromEnd == mermaid.PlainEnd && isHierarchyEnd(relation.ToEnd) {
+			layout.addEdge(relation.To, relation.From)
+		} else {
+			layout.addEdge(relation.From, relation.To)
+		}
+	}
+	layout.run()
+
+	// Create namespace containers
+	for _, namespace := range diagram.Namespaces {
+		key := namespacePrefix + namespace
+		x := layout.positions[key].X
+		y := layout.positions[key].Y
+		width := layout.sizes[key].Width
+		height := layout.sizes[key].Height
+
+		id := fmt.Sprintf("namespace_%d", cellID)
+		namespaceCells[key] = id
+		cells = append(cells, MxCell{
+			ID:     id,
+			Value:  namespace,
+			Style:  "swimlane;whiteSpace=wrap;html=1;container=1;collapsible=0;startSize=30;dashed=1;",
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &y,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
+	}
+
+	// Create UML class boxes with their members
+	for _, class := range diagram.Classes {
+		x := layout.positions[class.Name].X
+		y := layout.positions[class.Name].Y
+		width := layout.sizes[class.Name].Width
+		height := layout.sizes[class.Name].Height
+		headerHeight := classHeaderHeight(class)
+
+		parentID := "1"
+		if id, ok := namespaceCells[namespaceParent(class.Namespace)]; ok {
+			parentID = id
+		}
+
+		classID := fmt.Sprintf("class_%d", cellID)
+		classCells[class.Name] = classID
+		cells = append(cells, MxCell{
+			ID:     classID,
+			Value:  classTitle(class),
+			Style:  fmt.Sprintf("swimlane;fontStyle=%d;align=center;verticalAlign=top;childLayout=stackLayout;horizontal=1;startSize=%g;horizontalStack=0;resizeParent=1;resizeParentMax=0;resizeLast=0;collapsible=1;marginBottom=0;whiteSpace=wrap;html=1;", classTitleFontStyle(class), headerHeight),
+			Vertex: "1",
+			Parent: parentID,
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &y,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
+
+		rowY := headerHeight
+		addRow := func(value, style string, rowHeight float64) {
+			y := rowY
+			rowWidth := width
+			cells = append(cells, MxCell{
+				ID:     fmt.Sprintf("member_%d", cellID),
+				Value:  value,
+				Style:  style,
+				Vertex: "1",
+				Parent: classID,
+				Geometry: &MxGeometry{
+					Y:      &y,
+					Width:  &rowWidth,
+					Height: &rowHeight,
+					As:     "geometry",
+				},
+			})
+			cellID++
+			rowY += rowHeight
+		}
+
+		for _, field := range class.Fields {
+			addRow(html.EscapeString(formatClassMember(field, false)), classMemberStyle(field), ClassMemberHeight)
+		}
+		addRow("", "line;strokeWidth=1;fillColor=none;align=left;verticalAlign=middle;spacingTop=-1;spacingLeft=3;spacingRight=3;rotatable=0;labelPosition=right;points=[];portConstraint=eastwest;strokeColor=inherit;", ClassSeparatorHeight)
+		for _, method := range class.Methods {
+			addRow(html.EscapeString(formatClassMember(method, true)), classMemberStyle(method), ClassMemberHeight)
+		}
+	}
+
+	// Create relations
+	for _, relation := range diagram.Relations {
+		fromID := classCells[relation.From]
+		toID := classCells[relation.To]
+
+		if fromID == "" || toID == "" {
+			continue // Skip if class not found
+		}
+
+		style := "edgeStyle=orthogonalEdgeStyle;rounded=0;html=1;endSize=12;startSize=12;"
+		style += classArrowStyle("start", relation.FromEnd)
+		style += classArrowStyle("end", relation.ToEnd)
+		if relation.Dashed {
+			style += "dashed=1;"
+		}
+
+		relationID := fmt.Sprintf("relation_%d", cellID)
+		cells = append(cells, MxCell{
+			ID:     relationID,
+			Value:  html.EscapeString(relation.Label),
+			Style:  style,
+			Edge:   "1",
+			Parent: "1",
+			Source: fromID,
+			Target: toID,
+			Geometry: &MxGeometry{
+				Relative: "1",
+				As:       "geometry",
+			},
+		})
+		cellID++
+
+		// Cardinalities are edge labels pinned to either end
+		for _, label := range []struct {
+			text string
+			x    float64
+		}{
+			{relation.FromCardinality, -0.8},
+			{relation.ToCardinality, 0.8},
+		} {
+			if label.text == "" {
+				continue
+			}
+			x := label.x
+			cells = append(cells, MxCell{
+				ID:          fmt.Sprintf("cardinality_%d", cellID),
+				Value:       html.EscapeString(label.text),
+				Style:       "edgeLabel;resizable=0;html=1;align=center;verticalAlign=bottom;labelBackgroundColor=none;",
+				Vertex:      "1",
+				Connectable: "0",
+				Parent:      relationID,
+				Geometry: &MxGeometry{
+					X:        &x,
+					Relative: "1",
+					As:       "geometry",
+				},
+			})
+			cellID++
+		}
+	}
+
+	model.Root.MxCells = cells
+	return generateXMLOutput(model)
+}
+
+// namespacePrefix keeps namespace ids apart from class names in the layout.
+const namespacePrefix = "namespace:"
+
+func namespaceParent(namespace string) string {
+	if namespace == "" {
+		return ""
+	}
+	return namespacePrefix + namespace
+}
+
+func isHierarchyEnd(end mermaid.RelationEnd) bool {
+	return end == mermaid.InheritanceEnd || end == mermaid.CompositionEnd || end == mermaid.AggregationEnd
+}
+
+func classHeaderHeight(class mermaid.Class) float64 {
+	if len(class.Annotations) > 0 {
+		return ClassAnnotatedHeaderHeight
+	}
+	return ClassHeaderHeight
+}
+
+func classSize(class mermaid.Class) (float64, float64) {
+	longest := utf8.RuneCountInString(formatGeneric(class.Name + genericSuffix(class.Generic)))
+	for _, field := range class.Fields {
+		longest = max(longest, utf8.RuneCountInString(formatClassMember(field, false)))
+	}
+	for _, method := range class.Methods {
+		longest = max(longest, utf8.RuneCountInString(formatClassMember(method, true)))
+	}
+
+	width := max(ClassWidth, float64(longest)*ClassCharWidth+2*ClassCharWidth)
+	height := classHeaderHeight(class) + float64(len(class.Fields)+len(class.Methods))*ClassMemberHeight + ClassSeparatorHeight
+	return width, height
+}
+
+// classTitle renders the header, with annotations in guillemets above the
+// class name as UML does.
+func classTitle(class mermaid.Class) string {
+	name := html.EscapeString(formatGeneric(class.Name + genericSuffix(class.Generic)))
+	if len(class.Annotations) == 0 {
+		return name
+	}
+
+	var title strings.Builder
+	for _, annotation := range class.Annotations {
+		title.WriteString("«" + html.EscapeString(annotation) + "»<br>")
+	}
+	title.WriteString("<b>" + name + "</b>")
+	return title.String()
+}
+
+func classTitleFontStyle(class mermaid.Class) int {
+	if len(class.Annotations) > 0 {
+		return 0 // the name is bolded inline
+	}
+	return 1
+}
+
+func genericSuffix(generic string) string {
+	if generic == "" {
+		return ""
+	}
+	return "~" + generic + "~"
+}
+
+// formatGeneric turns Mermaid's "List~List~int~~" notation into
+// "List<List<int>>". A tilde directly followed by an identifier opens a type
+// parameter list, any other tilde closes one.
+func formatGeneric(text string) string {
+	var formatted strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '~' {
+			formatted.WriteByte(text[i])
+			continue
+		}
+		if i+1 < len(text) && isIdentifierByte(text[i+1]) && i > 0 && isIdentifierByte(text[i-1]) {
+			formatted.WriteByte('<')
+		} else {
+			formatted.WriteByte('>')
+		}
+	}
+	return formatted.String()
+}
+
+func isIdentifierByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// formatClassMember renders a member in UML notation, e.g. "+ area(): double".
+func formatClassMember(member mermaid.ClassMember, isMethod bool) string {
+	text := visibilitySymbol(member.Visibility) + member.Name
+	if isMethod {
+		text += "(" + member.Parameters + ")"
+	}
+	if member.Type != "" {
+		text += ": " + member.Type
+	}
+	return formatGeneric(text)
+}
+
+func visibilitySymbol(visibility mermaid.Visibility) string {
+	switch visibility {
+	case mermaid.PublicVisibility:
+		return "+ "
+	case mermaid.PrivateVisibility:
+		return "- "
+	case mermaid.ProtectedVisibility:
+		return "# "
+	case mermaid.PackageVisibility:
+		return "~ "
+	default:
+		return ""
+	}
+}
+
+// classMemberStyle underlines static members and italicizes abstract ones.
+func classMemberStyle(member mermaid.ClassMember) string {
+	fontStyle := 0
+	if member.IsStatic {
+		fontStyle |= 4
+	}
+	if member.IsAbstract {
+		fontStyle |= 2
+	}
+	return fmt.Sprintf("text;strokeColor=none;fillColor=none;align=left;verticalAlign=top;spacingLeft=4;spacingRight=4;overflow=hidden;rotatable=0;points=[[0,0.5],[1,0.5]];portConstraint=eastwest;whiteSpace=wrap;html=1;fontStyle=%d;", fontStyle)
+}
+
+// classArrowStyle returns the marker style for one side ("start" or "end")
+// of a relation edge.
+func classArrowStyle(side string, end mermaid.RelationEnd) string {
+	switch end {
+	case mermaid.InheritanceEnd:
+		return side + "Arrow=block;" + side + "Fill=0;"
+	case mermaid.CompositionEnd:
+		return side + "Arrow=diamondThin;" + side + "Fill=1;"
+	case mermaid.AggregationEnd:
+		return side + "Arrow=diamondThin;" + side + "Fill=0;"
+	case mermaid.AssociationEnd:
+		return side + "Arrow=open;" + side + "Fill=0;"
+	default:
+		return side + "Arrow=none;"
+	}
+}
@@ -0,0 +1,91 @@
+package drawio
+
+import (
+	"mermaid2drawio/internal/mermaid"
+	"strings"
+	"testing"
+)
+
+func TestGenerateClassDrawIOXML(t *testing.T) {
+	diagram := &mermaid.ClassDiagram{
+		Direction:  "TB",
+		Namespaces: []string{"Zoo"},
+		Classes: []mermaid.Class{
+			{
+				Name:        "Animal",
+				Annotations: []string{"interface"},
+				Fields: []mermaid.ClassMember{
+					{Name: "name", Type: "String", Visibility: mermaid.PublicVisibility},
+					{Name: "count", Type: "int", Visibility: mermaid.PrivateVisibility, IsStatic: true},
+				},
+				Methods: []mermaid.ClassMember{
+					{Name: "friends", Type: "List~Animal~", Visibility: mermaid.PublicVisibility, IsAbstract: true},
+				},
+			},
+			{Name: "Duck", Namespace: "Zoo"},
+		},
+		Relations: []mermaid.ClassRelation{
+			{From: "Animal", To: "Duck", FromEnd: mermaid.InheritanceEnd, ToCardinality: "many"},
+			{From: "Duck", To: "Animal", ToEnd: mermaid.AssociationEnd, Dashed: true, Label: "uses <T>", FromCardinality: "0..1 & more"},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"«interface»",
+		"childLayout=stackLayout",
+		"+ name: String",
+		"fontStyle=4;",
+		"fontStyle=2;",
+		"+ friends(): List&amp;lt;Animal&amp;gt;",
+		"line;strokeWidth=1;",
+		"startArrow=block;startFill=0;",
+		"endArrow=open;endFill=0;dashed=1;",
+		`value="many"`,
+		`value="uses &amp;lt;T&amp;gt;"`,
+		`value="0..1 &amp;amp; more"`,
+		`value="Zoo"`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML should contain %q", want)
+		}
+	}
+}
+
+func TestClassArrowStyles(t *testing.T) {
+	tests := []struct {
+		end   mermaid.RelationEnd
+		style string
+	}{
+		{mermaid.PlainEnd, "endArrow=none;"},
+		{mermaid.InheritanceEnd, "endArrow=block;endFill=0;"},
+		{mermaid.CompositionEnd, "endArrow=diamondThin;endFill=1;"},
+		{mermaid.AggregationEnd, "endArrow=diamondThin;endFill=0;"},
+		{mermaid.AssociationEnd, "endArrow=open;endFill=0;"},
+	}
+
+	for _, tt := range tests {
+		if style := classArrowStyle("end", tt.end); style != tt.style {
+			t.Errorf("End %v: expected %q, got %q", tt.end, tt.style, style)
+		}
+	}
+}
+
+func TestFormatGeneric(t *testing.T) {
+	tests := map[string]string{
+		"List~int~":         "List<int>",
+		"List~List~int~~":   "List<List<int>>",
+		"Map~K, V~ entries": "Map<K, V> entries",
+		"plain":             "plain",
+	}
+
+	for input, want := range tests {
+		if got := formatGeneric(input); got != want {
+			t.Errorf("formatGeneric(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
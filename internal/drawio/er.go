@@ -0,0 +1,367 @@
+package drawio
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"mermaid2drawio/internal/mermaid"
+)
+
+// Layout constants for ER tables
+const (
+	ERTableHeader    = 30.0
+	ERRowHeight      = 26.0
+	ERCharWidth      = 8.0
+	ERCellPadding    = 6.0
+	ERKeyColumnWidth = 40.0 // narrowest key column
+	ERColumnWidth    = 60.0 // narrowest name, type and comment column
+)
+
+// ERStyle selects how entities are drawn.
+type ERStyle int
+
+const (
+	ERListStyle  ERStyle = iota // one text row per attribute with key icons
+	ERTableStyle                // draw.io table with key, name and type columns
+)
+
+// ParseERStyle maps the names "list" and "table" to an ERStyle.
+func ParseERStyle(name string) (ERStyle, error) {
+	switch strings.ToLower(name) {
+	case "", "list":
+		return ERListStyle, nil
+	case "table":
+		return ERTableStyle, nil
+	default:
+		return ERListStyle, fmt.Errorf("unknown ER style %q (want list or table)", name)
+	}
+}
+
+// erTableCells draws an entity as a draw.io table shape. Primary key rows
+// come first in bold underlined type, separated from the other rows by a
+// divider; a comment column is added when any attribute has a comment.
+// rows maps attribute names to their row cells.
+func erTableCells(entity mermaid.Entity, x, y float64, cellID int) ([]MxCell, map[string]string, int) {
+	attributes := erTableRows(entity)
+	columns := erTableColumns(entity, attributes)
+	width, height := erTableSize(columns, attributes)
+
+	tableID := fmt.Sprintf("entity_table_%d", cellID)
+	cells := []MxCell{{
+		ID:     tableID,
+		Value:  html.EscapeString(entityLabel(entity)),
+		Style:  fmt.Sprintf("shape=table;startSize=%g;container=1;collapsible=1;childLayout=tableLayout;fixedRows=1;rowLines=0;fontStyle=1;align=center;resizeLast=1;whiteSpace=wrap;html=1;", ERTableHeader),
+		Vertex: "1",
+		Parent: "1",
+		Geometry: &MxGeometry{
+			X:      &x,
+			Y:      &y,
+			Width:  &width,
+			Height: &height,
+			As:     "geometry",
+		},
+	}}
+	rows := make(map[string]string)
+	cellID++
+
+	keyRows := 0
+	for _, attr := range attributes {
+		if attr.IsPK {
+			keyRows++
+		}
+	}
+
+	for i, attr := range attributes {
+		rowID := fmt.Sprintf("row_%d", cellID)
+		rowY := ERTableHeader + float64(i)*ERRowHeight
+		rowWidth := width
+		rowHeight := ERRowHeight
+		divider := 0
+		if i == keyRows-1 && keyRows < len(attributes) {
+			divider = 1
+		}
+		cells = append(cells, MxCell{
+			ID:     rowID,
+			Style:  fmt.Sprintf("shape=tableRow;horizontal=0;startSize=0;swimlaneHead=0;swimlaneBody=0;fillColor=none;collapsible=0;dropTarget=0;points=[[0,0.5],[1,0.5]];portConstraint=eastwest;top=0;left=0;right=0;bottom=%d;", divider),
+			Vertex: "1",
+			Parent: tableID,
+			Geometry: &MxGeometry{
+				Y:      &rowY,
+				Width:  &rowWidth,
+				Height: &rowHeight,
+				As:     "geometry",
+			},
+		})
+		if _, exists := rows[attr.Name]; !exists {
+			rows[attr.Name] = rowID
+		}
+		cellID++
+
+		nameStyle := "align=left;spacingLeft=6;"
+		if attr.IsPK {
+			nameStyle += "fontStyle=5;" // bold and underlined
+		}
+		values := []struct {
+			text  string
+			style string
+		}{
+			{erKeyMarker(attr), "align=center;fontStyle=1;"},
+			{attr.Name, nameStyle},
+			{erTypeText(attr), "align=left;spacingLeft=6;"},
+			{attr.Comment, "align=left;spacingLeft=6;fontStyle=2;fontColor=#808080;"},
+		}
+
+		columnX := 0.0
+		for j, column := range columns {
+			cellX := columnX
+			cellWidth := column
+			cellHeight := ERRowHeight
+			cells = append(cells, MxCell{
+				ID:     fmt.Sprintf("column_%d", cellID),
+				Value:  html.EscapeString(values[j].text),
+				Style:  "shape=partialRectangle;connectable=0;fillColor=none;top=0;left=0;bottom=0;right=0;overflow=hidden;whiteSpace=wrap;html=1;" + values[j].style,
+				Vertex: "1",
+				Parent: rowID,
+				Geometry: &MxGeometry{
+					X:      &cellX,
+					Width:  &cellWidth,
+					Height: &cellHeight,
+					As:     "geometry",
+				},
+			})
+			cellID++
+			columnX += column
+		}
+	}
+
+	return cells, rows, cellID
+}
+
+// erTableRows returns the attributes with primary keys moved to the top.
+func erTableRows(entity mermaid.Entity) []mermaid.Attribute {
+	rows := make([]mermaid.Attribute, 0, len(entity.Attributes))
+	for _, attr := range entity.Attributes {
+		if attr.IsPK {
+			rows = append(rows, attr)
+		}
+	}
+	for _, attr := range entity.Attributes {
+		if !attr.IsPK {
+			rows = append(rows, attr)
+		}
+	}
+	return rows
+}
+
+// erTableColumns sizes the key, name and type columns, and the comment
+// column when needed, to their longest text. Long titles widen the last
+// column.
+func erTableColumns(entity mermaid.Entity, attributes []mermaid.Attribute) []float64 {
+	columns := []float64{ERKeyColumnWidth, ERColumnWidth, ERColumnWidth}
+	hasComments := false
+	for _, attr := range attributes {
+		if attr.Comment != "" {
+			hasComments = true
+		}
+	}
+	if hasComments {
+		columns = append(columns, ERColumnWidth)
+	}
+
+	fit := func(column int, text string) {
+		width := float64(utf8.RuneCountInString(text))*ERCharWidth + 2*ERCellPadding
+		if column < len(columns) && width > columns[column] {
+			columns[column] = width
+		}
+	}
+	for _, attr := range attributes {
+		fit(0, erKeyMarker(attr))
+		fit(1, attr.Name)
+		fit(2, erTypeText(attr))
+		fit(3, attr.Comment)
+	}
+
+	width, _ := erTableSize(columns, attributes)
+	if titleWidth := float64(utf8.RuneCountInString(entityLabel(entity)))*ERCharWidth + 2*ERCellPadding; titleWidth > width {
+		columns[len(columns)-1] += titleWidth - width
+	}
+	return columns
+}
+
+func erTableSize(columns []float64, attributes []mermaid.Attribute) (float64, float64) {
+	width := 0.0
+	for _, column := range columns {
+		width += column
+	}
+	return width, ERTableHeader + float64(len(attributes))*ERRowHeight
+}
+
+// erEntitySize returns the size an entity is drawn at in the given style.
+func erEntitySize(entity mermaid.Entity, style ERStyle) (float64, float64) {
+	if style == ERTableStyle {
+		attributes := erTableRows(entity)
+		return erTableSize(erTableColumns(entity, attributes), attributes)
+	}
+	return EntityWidth, EntityHeight + float64(len(entity.Attributes))*AttributeHeight
+}
+
+// erLayout places each group of related entities with the layered layout,
+// left to right along the relationships. Groups follow each other in rows
+// that wrap past EntityRowWidth and are as tall as their tallest group.
+func erLayout(diagram *mermaid.ERDiagram, style ERStyle) (map[string]layoutPosition, map[string]layoutNode) {
+	positions := make(map[string]layoutPosition)
+	sizes := make(map[string]layoutNode)
+	for _, entity := range diagram.Entities {
+		width, height := erEntitySize(entity, style)
+		sizes[entity.Name] = layoutNode{ID: entity.Name, Width: width, Height: height}
+	}
+
+	x, y, rowHeight := StartX, StartY, 0.0
+	for _, group := range erGroups(diagram, sizes) {
+		var nodes []layoutNode
+		for _, name := range group {
+			nodes = append(nodes, sizes[name])
+		}
+		var edges []layoutEdge
+		for _, relationship := range diagram.Relationships {
+			if slices.Contains(group, relationship.From) {
+				edges = append(edges, layoutEdge{From: relationship.From, To: relationship.To})
+			}
+		}
+
+		groupPositions, width, height := layeredLayout(nodes, edges, "LR", EntityRankSpacing, EntitySpacing)
+		if x > StartX && x+width > StartX+EntityRowWidth {
+			x, y, rowHeight = StartX, y+rowHeight+EntityGroupSpacing, 0
+		}
+		for name, position := range groupPositions {
+			positions[name] = layoutPosition{X: x + position.X, Y: y + position.Y}
+		}
+		x += width + EntityGroupSpacing
+		rowHeight = max(rowHeight, height)
+	}
+	return positions, sizes
+}
+
+// erGroups splits the entities into groups connected by relationships, in
+// the order of their first entity.
+func erGroups(diagram *mermaid.ERDiagram, sizes map[string]layoutNode) [][]string {
+	group := make(map[string]int)
+	for i, entity := range diagram.Entities {
+		group[entity.Name] = i
+	}
+	var find func(name string) int
+	find = func(name string) int {
+		root := group[name]
+		if diagram.Entities[root].Name != name {
+			root = find(diagram.Entities[root].Name)
+			group[name] = root
+		}
+		return root
+	}
+	for _, relationship := range diagram.Relationships {
+		if _, ok := sizes[relationship.From]; !ok {
+			continue
+		}
+		if _, ok := sizes[relationship.To]; !ok {
+			continue
+		}
+		from, to := find(relationship.From), find(relationship.To)
+		group[diagram.Entities[max(from, to)].Name] = min(from, to)
+	}
+
+	var groups [][]string
+	index := make(map[int]int)
+	for _, entity := range diagram.Entities {
+		root := find(entity.Name)
+		if _, ok := index[root]; !ok {
+			index[root] = len(groups)
+			groups = append(groups, nil)
+		}
+		groups[index[root]] = append(groups[index[root]], entity.Name)
+	}
+	return groups
+}
+
+// erEdgeSides anchors a relationship on the sides of its entities that face
+// each other. Entities stacked in one column, and self-relationships, are
+// joined on their right sides.
+func erEdgeSides(relationship mermaid.Relationship, positions map[string]layoutPosition, sizes map[string]layoutNode) string {
+	from, to := positions[relationship.From], positions[relationship.To]
+	fromSize, toSize := sizes[relationship.From], sizes[relationship.To]
+
+	exitX, entryX := "1", "1"
+	switch {
+	case relationship.From == relationship.To:
+		return "exitX=1;exitY=0.3;exitDx=0;exitDy=0;entryX=1;entryY=0.7;entryDx=0;entryDy=0;"
+	case from.X+fromSize.Width <= to.X:
+		exitX, entryX = "1", "0"
+	case to.X+toSize.Width <= from.X:
+		exitX, entryX = "0", "1"
+	}
+	return fmt.Sprintf("exitX=%s;exitY=0.5;exitDx=0;exitDy=0;entryX=%s;entryY=0.5;entryDx=0;entryDy=0;", exitX, entryX)
+}
+
+// erKeyMarker lists the keys of an attribute, e.g. "PK,FK".
+func erKeyMarker(attr mermaid.Attribute) string {
+	var keys []string
+	if attr.IsPK {
+		keys = append(keys, "PK")
+	}
+	if attr.IsFK {
+		keys = append(keys, "FK")
+	}
+	if attr.IsUnique {
+		keys = append(keys, "UK")
+	}
+	return strings.Join(keys, ",")
+}
+
+func erTypeText(attr mermaid.Attribute) string {
+	text := formatGeneric(attr.Type)
+	if attr.IsNotNull {
+		text += " NOT NULL"
+	}
+	return text
+}
+
+// erKeyLink finds a foreign key in one entity of a relationship that refers
+// to the primary key of the other, and returns the attribute names used at
+// the From and To ends, or empty names when there is none.
+func erKeyLink(relationship mermaid.Relationship, entities map[string]mermaid.Entity) (string, string) {
+	from, to := entities[relationship.From], entities[relationship.To]
+	if fk, pk, ok := erForeignKey(to, from); ok {
+		return pk, fk
+	}
+	if fk, pk, ok := erForeignKey(from, to); ok {
+		return fk, pk
+	}
+	return "", ""
+}
+
+// erForeignKey returns a foreign key of child named like a primary key of
+// parent: the same name ("customer_id") or the parent name followed by the
+// key ("user_id" or "userId" for USER.id).
+func erForeignKey(child, parent mermaid.Entity) (string, string, bool) {
+	for _, fk := range child.Attributes {
+		if !fk.IsFK {
+			continue
+		}
+		for _, pk := range parent.Attributes {
+			if !pk.IsPK {
+				continue
+			}
+			if fk.Name == pk.Name || erNormalizeKey(fk.Name) == erNormalizeKey(parent.Name+pk.Name) {
+				return fk.Name, pk.Name, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// erNormalizeKey ignores case and separators when comparing key names.
+func erNormalizeKey(name string) string {
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(name))
+}
@@ -0,0 +1,278 @@
+package drawio
+
+import (
+	"strings"
+	"testing"
+
+	"mermaid2drawio/internal/mermaid"
+)
+
+func TestERTableStyle(t *testing.T) {
+	diagram := &mermaid.ERDiagram{
+		Entities: []mermaid.Entity{
+			{
+				Name: "CUSTOMER",
+				Attributes: []mermaid.Attribute{
+					{Name: "name", Type: "string", IsNotNull: true},
+					{Name: "id", Type: "int", IsPK: true, Comment: "primary"},
+					{Name: "address_id", Type: "int", IsFK: true},
+				},
+			},
+			{Name: "ORDER"},
+		},
+		Relationships: []mermaid.Relationship{
+			{From: "CUSTOMER", To: "ORDER", Type: mermaid.OneToMany, Label: "places"},
+		},
+	}
+
+	xml, err := GenerateDrawIOXMLWithOptions(diagram, Options{ERStyle: ERTableStyle})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(xml, `id="entity_table_2" value="CUSTOMER" style="shape=table;startSize=30;`) {
+		t.Error("Entity should be drawn as a table shape")
+	}
+	if strings.Contains(xml, "🔑") || strings.Contains(xml, "🔗") {
+		t.Error("Table style should not use key icons")
+	}
+	if strings.Count(xml, "shape=tableRow;") != 3 {
+		t.Error("Expected one row per attribute")
+	}
+
+	// The primary key row comes first, bold and underlined, with a divider
+	// below it
+	if !strings.Contains(xml, `id="row_3" style="shape=tableRow;horizontal=0;startSize=0;swimlaneHead=0;swimlaneBody=0;fillColor=none;collapsible=0;dropTarget=0;points=[[0,0.5],[1,0.5]];portConstraint=eastwest;top=0;left=0;right=0;bottom=1;"`) {
+		t.Error("Primary key row should come first with a divider")
+	}
+	if !strings.Contains(xml, `id="column_5" value="id"`) || !strings.Contains(xml, "spacingLeft=6;fontStyle=5;") {
+		t.Error("Primary key name should be bold and underlined")
+	}
+
+	for _, value := range []string{`value="PK"`, `value="FK"`, `value="string NOT NULL"`, `value="primary"`} {
+		if !strings.Contains(xml, value) {
+			t.Errorf("Expected a column cell with %s", value)
+		}
+	}
+
+	// Key, name, type and comment columns sized to their text
+	if !strings.Contains(xml, `<mxGeometry x="40" width="92" height="26" as="geometry">`) {
+		t.Error("Name column should fit the longest name")
+	}
+	if !strings.Contains(xml, `<mxGeometry x="50" y="50" width="332" height="108" as="geometry">`) {
+		t.Error("Table should span all columns and rows")
+	}
+
+	if !strings.Contains(xml, `value="places"`) || !strings.Contains(xml, `source="entity_table_2"`) {
+		t.Error("Relationships should connect the tables")
+	}
+}
+
+func TestERTableTitleWidth(t *testing.T) {
+	diagram := &mermaid.ERDiagram{
+		Entities: []mermaid.Entity{
+			{Name: "A", Alias: "A very long customer account title"},
+		},
+	}
+
+	xml, err := GenerateDrawIOXMLWithOptions(diagram, Options{ERStyle: ERTableStyle})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(xml, `width="284" height="30" as="geometry"`) {
+		t.Error("Table should be wide enough for its title")
+	}
+}
+
+func TestERLabelsEscapedInBothStyles(t *testing.T) {
+	diagram := &mermaid.ERDiagram{
+		Entities: []mermaid.Entity{{Name: "ORDER", Alias: "<b>Orders</b> & Co"}, {Name: "LINE"}},
+		Relationships: []mermaid.Relationship{
+			{From: "ORDER", To: "LINE", Type: mermaid.OneToMany, Label: "has <many>"},
+		},
+	}
+
+	for _, style := range []ERStyle{ERListStyle, ERTableStyle} {
+		xml, err := GenerateERDrawIOXMLWithStyle(diagram, style)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, want := range []string{
+			`value="&amp;lt;b&amp;gt;Orders&amp;lt;/b&amp;gt; &amp;amp; Co"`,
+			`value="has &amp;lt;many&amp;gt;"`,
+		} {
+			if !strings.Contains(xml, want) {
+				t.Errorf("Style %v: XML should contain %q", style, want)
+			}
+		}
+	}
+}
+
+func TestParseERStyle(t *testing.T) {
+	tests := []struct {
+		name    string
+		style   ERStyle
+		wantErr bool
+	}{
+		{"", ERListStyle, false},
+		{"list", ERListStyle, false},
+		{"Table", ERTableStyle, false},
+		{"grid", ERListStyle, true},
+	}
+
+	for _, tt := range tests {
+		style, err := ParseERStyle(tt.name)
+		if style != tt.style || (err != nil) != tt.wantErr {
+			t.Errorf("ParseERStyle(%q) = %v, %v", tt.name, style, err)
+		}
+	}
+}
+
+func TestERLayoutFollowsRelationships(t *testing.T) {
+	diagram := &mermaid.ERDiagram{
+		Entities: []mermaid.Entity{
+			{Name: "ORDER"},
+			{Name: "CUSTOMER"},
+			{Name: "LOG"},
+		},
+		Relationships: []mermaid.Relationship{
+			{From: "CUSTOMER", To: "ORDER", Label: "places"},
+			{From: "ORDER", To: "ORDER", Label: "splits"},
+		},
+	}
+
+	positions, _ := erLayout(diagram, ERListStyle)
+
+	// Related entities share a group laid out along the relationship;
+	// unrelated ones follow in their own group
+	if positions["CUSTOMER"] != (layoutPosition{X: 50, Y: 50}) || positions["ORDER"] != (layoutPosition{X: 370, Y: 50}) {
+		t.Errorf("Expected CUSTOMER left of ORDER, got %v and %v", positions["CUSTOMER"], positions["ORDER"])
+	}
+	if positions["LOG"] != (layoutPosition{X: 650, Y: 50}) {
+		t.Errorf("Expected LOG after the first group, got %v", positions["LOG"])
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(xml, `value="places" style="edgeStyle=entityRelationEdgeStyle;endArrow=none;html=1;rounded=0;exitX=1;exitY=0.5;exitDx=0;exitDy=0;entryX=0;entryY=0.5;`) {
+		t.Error("Relationship should leave CUSTOMER on the right and enter ORDER on the left")
+	}
+	if !strings.Contains(xml, `value="splits" style="edgeStyle=entityRelationEdgeStyle;endArrow=none;html=1;rounded=0;exitX=1;exitY=0.3;exitDx=0;exitDy=0;entryX=1;entryY=0.7;`) {
+		t.Error("Self-relationship should loop on the right side")
+	}
+}
+
+func TestERLayoutRowsFitTallTables(t *testing.T) {
+	tall := mermaid.Entity{Name: "TALL"}
+	for i := 0; i < 10; i++ {
+		tall.Attributes = append(tall.Attributes, mermaid.Attribute{Name: "field", Type: "int"})
+	}
+	diagram := &mermaid.ERDiagram{
+		Entities: []mermaid.Entity{tall, {Name: "B"}, {Name: "C"}, {Name: "D"}, {Name: "E"}},
+	}
+
+	positions, _ := erLayout(diagram, ERListStyle)
+
+	// Four groups fit in a row; the next row starts below the tallest table
+	if positions["D"] != (layoutPosition{X: 890, Y: 50}) {
+		t.Errorf("Expected D at the end of the first row, got %v", positions["D"])
+	}
+	if positions["E"] != (layoutPosition{X: 50, Y: 360}) {
+		t.Errorf("Expected E below the tall table, got %v", positions["E"])
+	}
+}
+
+func TestEREdgeSidesFacingEachOther(t *testing.T) {
+	positions := map[string]layoutPosition{"A": {X: 400, Y: 50}, "B": {X: 50, Y: 50}, "C": {X: 400, Y: 300}}
+	sizes := map[string]layoutNode{"A": {Width: 200}, "B": {Width: 200}, "C": {Width: 200}}
+
+	if sides := erEdgeSides(mermaid.Relationship{From: "A", To: "B"}, positions, sizes); !strings.HasPrefix(sides, "exitX=0;") || !strings.Contains(sides, "entryX=1;") {
+		t.Errorf("Expected A to leave on the left towards B, got %s", sides)
+	}
+	if sides := erEdgeSides(mermaid.Relationship{From: "A", To: "C"}, positions, sizes); !strings.HasPrefix(sides, "exitX=1;") || !strings.Contains(sides, "entryX=1;") {
+		t.Errorf("Expected stacked entities to be joined on the right, got %s", sides)
+	}
+}
+
+func TestERRelationshipsAnchoredToKeyRows(t *testing.T) {
+	diagram := &mermaid.ERDiagram{
+		Entities: []mermaid.Entity{
+			{
+				Name: "USER",
+				Attributes: []mermaid.Attribute{
+					{Name: "name", Type: "string"},
+					{Name: "id", Type: "int", IsPK: true},
+				},
+			},
+			{
+				Name: "ORDER",
+				Attributes: []mermaid.Attribute{
+					{Name: "id", Type: "int", IsPK: true},
+					{Name: "userId", Type: "int", IsFK: true},
+				},
+			},
+			{Name: "NOTE"},
+		},
+		Relationships: []mermaid.Relationship{
+			{From: "ORDER", To: "USER", Type: mermaid.ManyToOne, Label: "placed by"},
+			{From: "USER", To: "NOTE", Type: mermaid.OneToMany, Label: "writes"},
+		},
+	}
+
+	tests := []struct {
+		style       ERStyle
+		userIDRow   string
+		orderFKRow  string
+		userElement string
+	}{
+		{ERListStyle, "attr_4", "attr_7", "entity_header_2"},
+		{ERTableStyle, "row_3", "row_16", "entity_table_2"},
+	}
+
+	for _, tt := range tests {
+		xml, err := GenerateDrawIOXMLWithOptions(diagram, Options{ERStyle: tt.style})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// ORDER is ranked left of USER, so the edge leaves the FK row on the
+		// right and enters the PK row on the left
+		placedBy := `value="placed by" style="edgeStyle=entityRelationEdgeStyle;endArrow=none;html=1;rounded=0;exitX=1;exitY=0.5;exitDx=0;exitDy=0;entryX=0;entryY=0.5;`
+		if !strings.Contains(xml, placedBy) {
+			t.Errorf("Style %v: expected the edge between the facing sides", tt.style)
+		}
+		if !strings.Contains(xml, `source="`+tt.orderFKRow+`" target="`+tt.userIDRow+`"`) {
+			t.Errorf("Style %v: expected the edge from ORDER.userId to USER.id", tt.style)
+		}
+
+		// Without matching keys the entities themselves are connected
+		if !strings.Contains(xml, `source="`+tt.userElement+`"`) {
+			t.Errorf("Style %v: expected the edge without keys to start at USER", tt.style)
+		}
+	}
+}
+
+func TestERForeignKeyNames(t *testing.T) {
+	parent := mermaid.Entity{Name: "LINE-ITEM", Attributes: []mermaid.Attribute{{Name: "id", IsPK: true}}}
+	tests := []struct {
+		fk    string
+		isFK  bool
+		match bool
+	}{
+		{"line_item_id", true, true},
+		{"lineItemId", true, true},
+		{"id", true, true},
+		{"line_item_id", false, false},
+		{"order_id", true, false},
+	}
+
+	for _, tt := range tests {
+		child := mermaid.Entity{Name: "X", Attributes: []mermaid.Attribute{{Name: tt.fk, IsFK: tt.isFK}}}
+		if _, _, ok := erForeignKey(child, parent); ok != tt.match {
+			t.Errorf("erForeignKey(%s, FK=%v) = %v, want %v", tt.fk, tt.isFK, ok, tt.match)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package drawio
+
+import (
+	"fmt"
+	"html"
+	"unicode/utf8"
+
+	"mermaid2drawio/internal/mermaid"
+)
+
+// Layout constants for flowcharts
+const (
+	FlowNodeWidth       = 120.0
+	FlowNodeHeight      = 60.0
+	FlowCharWidth       = 8.0
+	FlowRankSpacing     = 80.0
+	FlowNodeSpacing     = 40.0
+	FlowCircleSize      = 80.0
+	FlowRhombusHeight   = 80.0
+	FlowSubgraphPadding = 20.0
+	FlowSubgraphHeader  = 30.0
+)
+
+func GenerateFlowchartDrawIOXML(diagram *mermaid.FlowchartDiagram) (string, error) {
+	model := createBaseModel()
+
+	cells := createDefaultCells()
+	cellID := 2
+	nodeCells := make(map[string]string)
+
+	layout := newNestedLayout(diagram.Direction, FlowRankSpacing, FlowNodeSpacing, FlowSubgraphPadding, FlowSubgraphHeader)
+	subgraphs := make(map[string]mermaid.Subgraph)
+	for _, subgraph := range diagram.Subgraphs {
+		subgraphs[subgraph.ID] = subgraph
+		titleWidth := float64(utf8.RuneCountInString(subgraph.Title))*FlowCharWidth + 2*FlowSubgraphPadding
+		layout.addContainer(subgraph.ID, subgraph.Parent, subgraph.Direction, titleWidth)
+	}
+	nodes := make(map[string]mermaid.FlowNode)
+	for _, node := range diagram.Nodes {
+		nodes[node.ID] = node
+		width, height := flowNodeSize(node)
+		layout.addNode(node.ID, node.Subgraph, width, height)
+	}
+	for _, edge := range diagram.Edges {
+		layout.addEdge(edge.From, edge.To)
+	}
+	layout.run()
+
+	// Create subgraph containers and node shapes, parents before children
+	var emit func(container, parentID string)
+	emit = func(container, parentID string) {
+		for _, child := range layout.children[container] {
+			x := layout.positions[child].X
+			y := layout.positions[child].Y
+			width := layout.sizes[child].Width
+			height := layout.sizes[child].Height
+
+			var cell MxCell
+			if layout.isContainer(child) {
+				cell = MxCell{
+					ID:    fmt.Sprintf("subgraph_%d", cellID),
+					Value: html.EscapeString(subgraphs[child].Title),
+					Style: "swimlane;whiteSpace=wrap;html=1;container=1;collapsible=0;startSize=30;",
+				}
+			} else {
+				node := nodes[child]
+				cell = MxCell{
+					ID:    fmt.Sprintf("node_%d", cellID),
+					Value: html.EscapeString(node.Label),
+					Style: flowNodeStyle(node.Shape),
+				}
+			}
+			cell.Vertex = "1"
+			cell.Parent = parentID
+			cell.Geometry = &MxGeometry{
+				X:      &x,
+				Y:      &y,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			}
+			nodeCells[child] = cell.ID
+			cells = append(cells, cell)
+			cellID++
+
+			if layout.isContainer(child) {
+				emit(child, cell.ID)
+			}
+		}
+	}
+	emit("", "1")
+
+	// Create edges between nodes and subgraphs
+	for _, edge := range diagram.Edges {
+		fromID := nodeCells[edge.From]
+		toID := nodeCells[edge.To]
+
+		if fromID == "" || toID == "" {
+			continue // Skip if node not found
+		}
+
+		edgeCell := MxCell{
+			ID:     fmt.Sprintf("edge_%d", cellID),
+			Value:  html.EscapeString(edge.Label),
+			Style:  flowEdgeStyle(edge),
+			Edge:   "1",
+			Parent: "1",
+			Source: fromID,
+			Target: toID,
+			Geometry: &MxGeometry{
+				Relative: "1",
+				As:       "geometry",
+			},
+		}
+		cells = append(cells, edgeCell)
+		cellID++
+	}
+
+	model.Root.MxCells = cells
+	return generateXMLOutput(model)
+}
+
+// flowNodeSize grows the default node width with the label so that long
+// labels do not wrap into unreadable columns.
+func flowNodeSize(node mermaid.FlowNode) (float64, float64) {
+	width := FlowNodeWidth
+	if labelWidth := float64(utf8.RuneCountInString(node.Label))*FlowCharWidth + 2*FlowCharWidth; labelWidth > width {
+		width = labelWidth
+	}
+
+	switch node.Shape {
+	case mermaid.CircleShape, mermaid.DoubleCircleShape:
+		size := FlowCircleSize
+		if width*0.75 > size {
+			size = width * 0.75
+		}
+		return size, size
+	case mermaid.RhombusShape:
+		return width + 2*FlowCharWidth, FlowRhombusHeight
+	default:
+		return width, FlowNodeHeight
+	}
+}
+
+func flowNodeStyle(shape mermaid.NodeShape) string {
+	switch shape {
+	case mermaid.RoundedShape:
+		return "rounded=1;whiteSpace=wrap;html=1;"
+	case mermaid.StadiumShape:
+		return "rounded=1;arcSize=50;whiteSpace=wrap;html=1;"
+	case mermaid.SubroutineShape:
+		return "shape=process;whiteSpace=wrap;html=1;backgroundOutline=1;"
+	case mermaid.CylinderShape:
+		return "shape=cylinder3;whiteSpace=wrap;html=1;boundedLbl=1;backgroundOutline=1;size=15;"
+	case mermaid.CircleShape:
+		return "ellipse;whiteSpace=wrap;html=1;aspect=fixed;"
+	case mermaid.DoubleCircleShape:
+		return "ellipse;shape=doubleEllipse;whiteSpace=wrap;html=1;aspect=fixed;"
+	case mermaid.AsymmetricShape:
+		return "shape=step;perimeter=stepPerimeter;whiteSpace=wrap;html=1;fixedSize=1;size=15;flipH=1;"
+	case mermaid.RhombusShape:
+		return "rhombus;whiteSpace=wrap;html=1;"
+	case mermaid.HexagonShape:
+		return "shape=hexagon;perimeter=hexagonPerimeter2;whiteSpace=wrap;html=1;fixedSize=1;"
+	case mermaid.ParallelogramShape:
+		return "shape=parallelogram;perimeter=parallelogramPerimeter;whiteSpace=wrap;html=1;fixedSize=1;"
+	case mermaid.ParallelogramAltShape:
+		return "shape=parallelogram;perimeter=parallelogramPerimeter;whiteSpace=wrap;html=1;fixedSize=1;flipH=1;"
+	case mermaid.TrapezoidShape:
+		return "shape=trapezoid;perimeter=trapezoidPerimeter;whiteSpace=wrap;html=1;fixedSize=1;"
+	case mermaid.TrapezoidAltShape:
+		return "shape=trapezoid;perimeter=trapezoidPerimeter;whiteSpace=wrap;html=1;fixedSize=1;flipV=1;"
+	default:
+		return "rounded=0;whiteSpace=wrap;html=1;"
+	}
+}
+
+func flowEdgeStyle(edge mermaid.FlowEdge) string {
+	style := "edgeStyle=orthogonalEdgeStyle;rounded=0;orthogonalLoop=1;jettySize=auto;html=1;"
+	style += "startArrow=" + flowArrowStyle(edge.StartArrow) + ";"
+	style += "endArrow=" + flowArrowStyle(edge.EndArrow) + ";"
+
+	switch edge.Stroke {
+	case mermaid.DottedStroke:
+		style += "dashed=1;"
+	case mermaid.ThickStroke:
+		style += "strokeWidth=3;"
+	case mermaid.InvisibleStroke:
+		style += "strokeColor=none;"
+	}
+	return style
+}
+
+func flowArrowStyle(head mermaid.ArrowHead) string {
+	switch head {
+	case mermaid.PointHead:
+		return "classic"
+	case mermaid.CircleHead:
+		return "oval"
+	case mermaid.CrossHead:
+		return "cross"
+	default:
+		return "none"
+	}
+}
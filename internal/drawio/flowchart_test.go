@@ -0,0 +1,187 @@
+package drawio
+
+import (
+	"encoding/xml"
+	"mermaid2drawio/internal/mermaid"
+	"strings"
+	"testing"
+)
+
+func TestGenerateFlowchartDrawIOXML(t *testing.T) {
+	diagram := &mermaid.FlowchartDiagram{
+		Direction: "TB",
+		Nodes: []mermaid.FlowNode{
+			{ID: "A", Label: "Start", Shape: mermaid.StadiumShape},
+			{ID: "B", Label: "Ok?", Shape: mermaid.RhombusShape},
+			{ID: "C", Label: "Store", Shape: mermaid.CylinderShape},
+		},
+		Edges: []mermaid.FlowEdge{
+			{From: "A", To: "B", EndArrow: mermaid.PointHead},
+			{From: "B", To: "C", Label: "yes", Stroke: mermaid.DottedStroke, EndArrow: mermaid.PointHead},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"Start", "rhombus;", "shape=cylinder3;", "yes", "endArrow=classic;", "dashed=1;"} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML should contain %q", want)
+		}
+	}
+
+	if strings.Count(xml, `edge="1"`) != 2 {
+		t.Errorf("Expected 2 edges, got %d", strings.Count(xml, `edge="1"`))
+	}
+}
+
+func TestFlowchartLabelsAreEscaped(t *testing.T) {
+	diagram := &mermaid.FlowchartDiagram{
+		Direction: "TB",
+		Nodes: []mermaid.FlowNode{
+			{ID: "A", Label: "a < b"},
+			{ID: "B", Label: "R&D"},
+		},
+		Edges: []mermaid.FlowEdge{
+			{From: "A", To: "B", Label: "<i>x</i> & y", EndArrow: mermaid.PointHead},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`value="a &amp;lt; b"`,
+		`value="R&amp;amp;D"`,
+		`value="&amp;lt;i&amp;gt;x&amp;lt;/i&amp;gt; &amp;amp; y"`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML should contain %q", want)
+		}
+	}
+}
+
+func TestFlowNodeStyles(t *testing.T) {
+	tests := []struct {
+		shape mermaid.NodeShape
+		style string
+	}{
+		{mermaid.RectangleShape, "rounded=0;"},
+		{mermaid.CircleShape, "ellipse;"},
+		{mermaid.HexagonShape, "shape=hexagon;"},
+		{mermaid.ParallelogramShape, "shape=parallelogram;"},
+		{mermaid.TrapezoidShape, "shape=trapezoid;"},
+		{mermaid.SubroutineShape, "shape=process;"},
+	}
+
+	for _, tt := range tests {
+		if style := flowNodeStyle(tt.shape); !strings.Contains(style, tt.style) {
+			t.Errorf("Shape %v: expected style containing %q, got %q", tt.shape, tt.style, style)
+		}
+	}
+}
+
+func TestFlowchartLayoutDirection(t *testing.T) {
+	nodes := []layoutNode{
+		{ID: "A", Width: 100, Height: 50},
+		{ID: "B", Width: 100, Height: 50},
+	}
+	edges := []layoutEdge{{From: "A", To: "B"}}
+
+	positions, _, _ := layeredLayout(nodes, edges, "TB", 80, 40)
+	if positions["B"].Y <= positions["A"].Y {
+		t.Errorf("TB layout should place B below A, got %v and %v", positions["A"], positions["B"])
+	}
+
+	positions, _, _ = layeredLayout(nodes, edges, "LR", 80, 40)
+	if positions["B"].X <= positions["A"].X {
+		t.Errorf("LR layout should place B right of A, got %v and %v", positions["A"], positions["B"])
+	}
+
+	positions, _, _ = layeredLayout(nodes, edges, "BT", 80, 40)
+	if positions["B"].Y >= positions["A"].Y {
+		t.Errorf("BT layout should place B above A, got %v and %v", positions["A"], positions["B"])
+	}
+}
+
+func TestFlowchartLayoutCycle(t *testing.T) {
+	nodes := []layoutNode{
+		{ID: "A", Width: 100, Height: 50},
+		{ID: "B", Width: 100, Height: 50},
+		{ID: "C", Width: 100, Height: 50},
+	}
+	edges := []layoutEdge{{From: "A", To: "B"}, {From: "B", To: "C"}, {From: "C", To: "A"}}
+
+	ranks := assignRanks(nodes, edges)
+	if ranks["A"] != 0 || ranks["B"] != 1 || ranks["C"] != 2 {
+		t.Errorf("Expected ranks 0/1/2 ignoring the back edge, got %v", ranks)
+	}
+}
+
+func TestGenerateFlowchartSubgraphContainers(t *testing.T) {
+	diagram := &mermaid.FlowchartDiagram{
+		Direction: "LR",
+		Nodes: []mermaid.FlowNode{
+			{ID: "client", Label: "client"},
+			{ID: "api", Label: "api", Subgraph: "backend"},
+			{ID: "db", Label: "db", Shape: mermaid.CylinderShape, Subgraph: "storage"},
+		},
+		Subgraphs: []mermaid.Subgraph{
+			{ID: "backend", Title: "Backend"},
+			{ID: "storage", Title: "Storage", Parent: "backend"},
+		},
+		Edges: []mermaid.FlowEdge{
+			{From: "client", To: "api", EndArrow: mermaid.PointHead},
+			{From: "api", To: "storage", EndArrow: mermaid.PointHead},
+		},
+	}
+
+	output, err := GenerateFlowchartDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var model MxGraphModel
+	if err := xml.Unmarshal([]byte(output), &model); err != nil {
+		t.Fatalf("Output should be valid XML: %v", err)
+	}
+
+	cells := make(map[string]MxCell)
+	byValue := make(map[string]MxCell)
+	for _, cell := range model.Root.MxCells {
+		cells[cell.ID] = cell
+		if cell.Vertex == "1" {
+			byValue[cell.Value] = cell
+		}
+	}
+
+	backend, storage := byValue["Backend"], byValue["Storage"]
+	if !strings.Contains(backend.Style, "container=1") || backend.Parent != "1" {
+		t.Errorf("Backend should be a top-level container, got %+v", backend)
+	}
+	if storage.Parent != backend.ID {
+		t.Errorf("Storage should be nested in Backend, got parent %q", storage.Parent)
+	}
+	if byValue["api"].Parent != backend.ID || byValue["db"].Parent != storage.ID || byValue["client"].Parent != "1" {
+		t.Error("Nodes should use their subgraph container as parent")
+	}
+
+	// Child geometry is relative to the container
+	if x := *byValue["api"].Geometry.X; x >= *backend.Geometry.Width {
+		t.Errorf("api x=%v should be relative to its container of width %v", x, *backend.Geometry.Width)
+	}
+
+	edgeToSubgraph := false
+	for _, cell := range model.Root.MxCells {
+		if cell.Edge == "1" && cell.Target == storage.ID && cells[cell.Source].Value == "api" {
+			edgeToSubgraph = true
+		}
+	}
+	if !edgeToSubgraph {
+		t.Error("Edge targeting a subgraph id should connect to the container cell")
+	}
+}
@@ -0,0 +1,438 @@
+package drawio
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"mermaid2drawio/internal/mermaid"
+)
+
+// Layout constants for gantt charts
+const (
+	GanttDayWidth      = 30.0   // width of one day unless the chart is too long
+	GanttMaxChartWidth = 1200.0 // long schedules are squeezed to this width
+	GanttLaneHeader    = 140.0  // section title column
+	GanttRowHeight     = 36.0
+	GanttBarHeight     = 24.0
+	GanttAxisHeight    = 30.0
+	GanttTitleHeight   = 40.0
+	GanttMinTickWidth  = 80.0 // minimum distance between axis labels
+	GanttCharWidth     = 7.0
+)
+
+const defaultGanttAxisFormat = "%Y-%m-%d"
+
+// ganttNow is replaced in tests to pin the today marker.
+var ganttNow = time.Now
+
+// ganttScale maps times onto x coordinates relative to the lane body.
+type ganttScale struct {
+	start    time.Time
+	end      time.Time
+	dayWidth float64
+}
+
+func (s ganttScale) x(t time.Time) float64 {
+	return t.Sub(s.start).Hours() / 24 * s.dayWidth
+}
+
+func (s ganttScale) width() float64 {
+	return s.x(s.end)
+}
+
+func GenerateGanttDrawIOXML(diagram *mermaid.GanttDiagram) (string, error) {
+	model := createBaseModel()
+
+	cells := createDefaultCells()
+	cellID := 2
+
+	if len(diagram.Tasks) == 0 {
+		model.Root.MxCells = cells
+		return generateXMLOutput(model)
+	}
+
+	scale := newGanttScale(diagram)
+	chartWidth := scale.width()
+	totalWidth := GanttLaneHeader + chartWidth
+
+	y := 0.0
+	if diagram.Title != "" {
+		x, titleY, width, height := 0.0, y, totalWidth, GanttTitleHeight
+		cells = append(cells, MxCell{
+			ID:     fmt.Sprintf("gantt_%d", cellID),
+			Value:  html.EscapeString(diagram.Title),
+			Style:  "text;html=1;align=center;verticalAlign=middle;fontStyle=1;fontSize=16;",
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &titleY,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
+		y += GanttTitleHeight
+	}
+
+	// Group tasks into lanes, keeping unsectioned tasks in a leading lane
+	sections := diagram.Sections
+	for _, task := range diagram.Tasks {
+		if task.Section == "" {
+			sections = append([]string{""}, sections...)
+			break
+		}
+	}
+	lanes := make(map[string][]int)
+	for i, task := range diagram.Tasks {
+		lanes[task.Section] = append(lanes[task.Section], i)
+	}
+
+	axisY := y
+	bodyTop := axisY + GanttAxisHeight
+	bodyBottom := bodyTop
+	for _, section := range sections {
+		bodyBottom += float64(len(lanes[section])) * GanttRowHeight
+	}
+
+	// Excluded days are shaded behind everything else
+	for day := scale.start; day.Before(scale.end); day = day.AddDate(0, 0, 1) {
+		if !diagram.IsExcluded(day) || (day.After(scale.start) && diagram.IsExcluded(day.AddDate(0, 0, -1))) {
+			continue
+		}
+		last := day
+		for last.AddDate(0, 0, 1).Before(scale.end) && diagram.IsExcluded(last.AddDate(0, 0, 1)) {
+			last = last.AddDate(0, 0, 1)
+		}
+		x := GanttLaneHeader + scale.x(day)
+		width := scale.x(last.AddDate(0, 0, 1)) - scale.x(day)
+		height := bodyBottom - bodyTop
+		cells = append(cells, MxCell{
+			ID:     fmt.Sprintf("excluded_%d", cellID),
+			Style:  "rounded=0;whiteSpace=wrap;html=1;fillColor=#eeeeee;strokeColor=none;",
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &bodyTop,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
+	}
+
+	// Date axis with a label and a grid line per tick
+	axisFormat := diagram.AxisFormat
+	if axisFormat == "" {
+		axisFormat = defaultGanttAxisFormat
+	}
+	ticks := ganttTicks(scale)
+	for i, tick := range ticks {
+		x := GanttLaneHeader + scale.x(tick)
+		next := chartWidth
+		if i+1 < len(ticks) {
+			next = scale.x(ticks[i+1])
+		}
+		width, height := GanttLaneHeader+next-x, GanttAxisHeight
+		cells = append(cells, MxCell{
+			ID:     fmt.Sprintf("axis_%d", cellID),
+			Value:  html.EscapeString(formatGanttDate(tick, axisFormat)),
+			Style:  "text;html=1;align=left;verticalAlign=middle;fontSize=11;",
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &axisY,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
+		cells = append(cells, ganttLineCell(fmt.Sprintf("grid_%d", cellID), "endArrow=none;html=1;dashed=1;strokeColor=#d0d0d0;", x, bodyTop, x, bodyBottom))
+		cellID++
+	}
+	cells = append(cells, ganttLineCell(fmt.Sprintf("axis_%d", cellID), "endArrow=none;html=1;", GanttLaneHeader, bodyTop, totalWidth, bodyTop))
+	cellID++
+
+	// Section swimlanes holding one row per task
+	taskCells := make([]string, len(diagram.Tasks))
+	taskIDs := make(map[string]int)
+	laneY := bodyTop
+	for index, section := range sections {
+		lane := lanes[section]
+		laneID := fmt.Sprintf("section_%d", cellID)
+		laneX, laneWidth := 0.0, totalWidth
+		laneTop, laneHeight := laneY, float64(len(lane))*GanttRowHeight
+		cells = append(cells, MxCell{
+			ID:     laneID,
+			Value:  html.EscapeString(section),
+			Style:  fmt.Sprintf("swimlane;horizontal=0;startSize=%g;html=1;collapsible=0;fillColor=%s;swimlaneFillColor=none;", GanttLaneHeader, ganttLaneColor(index)),
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &laneX,
+				Y:      &laneTop,
+				Width:  &laneWidth,
+				Height: &laneHeight,
+				As:     "geometry",
+			},
+		})
+		cellID++
+
+		for row, taskIndex := range lane {
+			task := diagram.Tasks[taskIndex]
+			x := GanttLaneHeader + scale.x(task.Start)
+			width := scale.x(task.End) - scale.x(task.Start)
+			if task.Milestone {
+				x += width/2 - GanttBarHeight/2
+				width = GanttBarHeight
+			}
+			taskY, height := float64(row)*GanttRowHeight+(GanttRowHeight-GanttBarHeight)/2, GanttBarHeight
+
+			taskCells[taskIndex] = fmt.Sprintf("task_%d", cellID)
+			if task.ID != "" {
+				taskIDs[task.ID] = taskIndex
+			}
+			cells = append(cells, MxCell{
+				ID:     taskCells[taskIndex],
+				Value:  html.EscapeString(task.Name),
+				Style:  ganttTaskStyle(task, width),
+				Vertex: "1",
+				Parent: laneID,
+				Geometry: &MxGeometry{
+					X:      &x,
+					Y:      &taskY,
+					Width:  &width,
+					Height: &height,
+					As:     "geometry",
+				},
+			})
+			cellID++
+		}
+		laneY += laneHeight
+	}
+
+	// Dependency connectors from the end of each predecessor
+	for i, task := range diagram.Tasks {
+		for _, after := range task.After {
+			source, ok := taskIDs[after]
+			if !ok {
+				continue
+			}
+			cells = append(cells, MxCell{
+				ID:     fmt.Sprintf("dependency_%d", cellID),
+				Style:  "edgeStyle=orthogonalEdgeStyle;rounded=1;html=1;endArrow=block;endFill=1;exitX=1;exitY=0.5;entryX=0;entryY=0.5;strokeColor=#666666;",
+				Edge:   "1",
+				Parent: "1",
+				Source: taskCells[source],
+				Target: taskCells[i],
+				Geometry: &MxGeometry{
+					Relative: "1",
+					As:       "geometry",
+				},
+			})
+			cellID++
+		}
+	}
+
+	if today := ganttNow(); diagram.TodayMarker != "off" && !today.Before(scale.start) && today.Before(scale.end) {
+		x := GanttLaneHeader + scale.x(today)
+		cells = append(cells, ganttLineCell(fmt.Sprintf("today_%d", cellID), "endArrow=none;html=1;"+ganttTodayStyle(diagram.TodayMarker), x, axisY, x, bodyBottom))
+		cellID++
+	}
+
+	model.Root.MxCells = cells
+	return generateXMLOutput(model)
+}
+
+// newGanttScale spans whole days from the earliest start to the latest end
+// and shrinks the day width when the chart would get too wide.
+func newGanttScale(diagram *mermaid.GanttDiagram) ganttScale {
+	start, end := diagram.Tasks[0].Start, diagram.Tasks[0].End
+	for _, task := range diagram.Tasks {
+		start = minTime(start, task.Start)
+		end = maxTime(end, maxTime(task.Start, task.End))
+		if task.Milestone {
+			end = maxTime(end, task.Start.AddDate(0, 0, 1))
+		}
+	}
+	start = truncateDay(start)
+	if truncateDay(end).Equal(end) && end.After(start) {
+		end = truncateDay(end)
+	} else {
+		end = truncateDay(end).AddDate(0, 0, 1)
+	}
+
+	scale := ganttScale{start: start, end: end, dayWidth: GanttDayWidth}
+	if days := end.Sub(start).Hours() / 24; days*GanttDayWidth > GanttMaxChartWidth {
+		scale.dayWidth = GanttMaxChartWidth / days
+	}
+	return scale
+}
+
+// ganttTicks picks daily, weekly or monthly ticks so that labels keep at
+// least GanttMinTickWidth apart.
+func ganttTicks(scale ganttScale) []time.Time {
+	var ticks []time.Time
+	for _, step := range []int{1, 7, 14} {
+		if float64(step)*scale.dayWidth >= GanttMinTickWidth {
+			for tick := scale.start; tick.Before(scale.end); tick = tick.AddDate(0, 0, step) {
+				ticks = append(ticks, tick)
+			}
+			return ticks
+		}
+	}
+
+	months := int(math.Ceil(GanttMinTickWidth / (30 * scale.dayWidth)))
+	ticks = append(ticks, scale.start)
+	first := time.Date(scale.start.Year(), scale.start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for tick := first.AddDate(0, months, 0); tick.Before(scale.end); tick = tick.AddDate(0, months, 0) {
+		ticks = append(ticks, tick)
+	}
+	return ticks
+}
+
+func ganttLineCell(id, style string, x1, y1, x2, y2 float64) MxCell {
+	return MxCell{
+		ID:     id,
+		Style:  style,
+		Edge:   "1",
+		Parent: "1",
+		Geometry: &MxGeometry{
+			Relative: "1",
+			As:       "geometry",
+			Points: []MxPoint{
+				{X: x1, Y: y1, As: "sourcePoint"},
+				{X: x2, Y: y2, As: "targetPoint"},
+			},
+		},
+	}
+}
+
+// ganttTaskStyle follows Mermaid's default theme colors. Labels that do not
+// fit inside a bar are placed to its right.
+func ganttTaskStyle(task mermaid.GanttTask, width float64) string {
+	fill, stroke := "#8a90dd", "#534fbc"
+	switch {
+	case task.Done:
+		fill, stroke = "#d3d3d3", "#808080"
+	case task.Active:
+		fill = "#bfc7ff"
+	}
+	style := "rounded=1;arcSize=20;"
+	if task.Milestone {
+		style = "rhombus;"
+	}
+	style += "whiteSpace=wrap;html=1;"
+	if task.Critical {
+		stroke = "#ff0000"
+		if !task.Done && !task.Active {
+			fill = "#ff8888"
+		}
+		style += "strokeWidth=2;"
+	}
+	style += fmt.Sprintf("fillColor=%s;strokeColor=%s;", fill, stroke)
+
+	if task.Milestone || float64(utf8.RuneCountInString(task.Name))*GanttCharWidth > width {
+		style += "labelPosition=right;verticalLabelPosition=middle;align=left;verticalAlign=middle;spacingLeft=4;"
+	}
+	return style
+}
+
+func ganttLaneColor(index int) string {
+	if index%2 == 0 {
+		return "#dae8fc"
+	}
+	return "#e1d5e7"
+}
+
+// ganttTodayStyle maps Mermaid's CSS-like todayMarker style onto draw.io
+// stroke properties.
+func ganttTodayStyle(marker string) string {
+	strokeColor, strokeWidth, opacity := "#ff0000", "2", ""
+	for _, declaration := range strings.FieldsFunc(marker, func(r rune) bool { return r == ',' || r == ';' }) {
+		name, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(name) {
+		case "stroke":
+			strokeColor = value
+		case "stroke-width":
+			strokeWidth = strings.TrimSuffix(value, "px")
+		case "opacity", "stroke-opacity":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				opacity = strconv.Itoa(int(math.Round(f * 100)))
+			}
+		}
+	}
+	style := fmt.Sprintf("strokeColor=%s;strokeWidth=%s;", strokeColor, strokeWidth)
+	if opacity != "" {
+		style += fmt.Sprintf("opacity=%s;", opacity)
+	}
+	return style
+}
+
+var strftimeLayouts = map[byte]string{
+	'Y': "2006", 'y': "06",
+	'm': "01", 'd': "02", 'e': "2",
+	'b': "Jan", 'B': "January",
+	'a': "Mon", 'A': "Monday",
+	'H': "15", 'I': "03", 'M': "04", 'S': "05", 'p': "PM",
+}
+
+// formatGanttDate formats t with the strftime directives Mermaid accepts in
+// axisFormat.
+func formatGanttDate(t time.Time, format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch directive := format[i]; directive {
+		case '%':
+			b.WriteByte('%')
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		default:
+			if layout, ok := strftimeLayouts[directive]; ok {
+				b.WriteString(t.Format(layout))
+			} else {
+				b.WriteByte('%')
+				b.WriteByte(directive)
+			}
+		}
+	}
+	return b.String()
+}
+
+func truncateDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,146 @@
+package drawio
+
+import (
+	"mermaid2drawio/internal/mermaid"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateGanttDrawIOXML(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2024, time.January, day, 0, 0, 0, 0, time.UTC)
+	}
+	diagram := &mermaid.GanttDiagram{
+		Title:           "Plan & build",
+		AxisFormat:      "%b %e",
+		ExcludeWeekends: true,
+		Sections:        []string{"Design", "Build"},
+		Tasks: []mermaid.GanttTask{
+			{ID: "spec", Name: "Spec", Section: "Design", Start: date(1), End: date(4), Done: true},
+			{ID: "review", Name: "Review", Section: "Design", Start: date(4), End: date(8), After: []string{"spec"}, Critical: true},
+			{ID: "ship", Name: "Ship", Section: "Build", Start: date(8), End: date(8), After: []string{"review"}, Milestone: true},
+		},
+	}
+
+	defer func(now func() time.Time) { ganttNow = now }(ganttNow)
+	ganttNow = func() time.Time { return date(3) }
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`value="Plan &amp;amp; build"`,
+		`value="Jan 1"`,
+		`value="Jan 8"`,
+		// Sections are horizontal swimlanes below the 30px axis
+		`value="Design" style="swimlane;horizontal=0;startSize=140;`,
+		`<mxGeometry x="0" y="70" width="380" height="72" as="geometry">`,
+		`<mxGeometry x="0" y="142" width="380" height="36" as="geometry">`,
+		// Bars are scaled at 30px per day inside their lane
+		`<mxGeometry x="140" y="6" width="90" height="24" as="geometry">`,
+		`<mxGeometry x="230" y="42" width="120" height="24" as="geometry">`,
+		"fillColor=#d3d3d3;strokeColor=#808080;",
+		"strokeWidth=2;fillColor=#ff8888;strokeColor=#ff0000;",
+		// The milestone diamond is centered on its date
+		"rhombus;",
+		`<mxGeometry x="338" y="6" width="24" height="24" as="geometry">`,
+		// The weekend is shaded
+		`<mxGeometry x="290" y="70" width="60" height="108" as="geometry">`,
+		// Today marker at January 3rd
+		`<mxPoint x="200" y="40" as="sourcePoint">`,
+		"strokeColor=#ff0000;strokeWidth=2;",
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML should contain %q", want)
+		}
+	}
+
+	if count := strings.Count(xml, `id="dependency_`); count != 2 {
+		t.Errorf("Expected 2 dependency connectors, got %d", count)
+	}
+	if !strings.Contains(xml, `source="task_`) {
+		t.Errorf("Dependency connectors should link task bars")
+	}
+}
+
+func TestGanttDependencyWithoutTaskID(t *testing.T) {
+	day := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	diagram := &mermaid.GanttDiagram{
+		TodayMarker: "off",
+		Tasks: []mermaid.GanttTask{
+			{ID: "a1", Name: "Design", Start: day, End: day.AddDate(0, 0, 2)},
+			{Name: "Build", Start: day.AddDate(0, 0, 2), End: day.AddDate(0, 0, 12), After: []string{"a1"}},
+		},
+	}
+
+	xml, err := GenerateGanttDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The axis and the lane come first, so the bars are task_8 and task_9
+	if !strings.Contains(xml, `id="task_8" value="Design"`) || !strings.Contains(xml, `id="task_9" value="Build"`) {
+		t.Fatalf("Unexpected task cells in %s", xml)
+	}
+	if !strings.Contains(xml, `source="task_8" target="task_9"`) {
+		t.Errorf("Build has no id of its own but should still be connected after a1")
+	}
+}
+
+func TestGanttTodayMarkerOff(t *testing.T) {
+	day := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	diagram := &mermaid.GanttDiagram{
+		TodayMarker: "off",
+		Tasks:       []mermaid.GanttTask{{Name: "A", Start: day, End: day.AddDate(0, 0, 2)}},
+	}
+
+	defer func(now func() time.Time) { ganttNow = now }(ganttNow)
+	ganttNow = func() time.Time { return day.Add(time.Hour) }
+
+	xml, err := GenerateGanttDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(xml, `id="today_`) {
+		t.Errorf("todayMarker off should not draw a marker")
+	}
+}
+
+func TestGanttTodayStyle(t *testing.T) {
+	tests := []struct {
+		marker   string
+		expected string
+	}{
+		{"", "strokeColor=#ff0000;strokeWidth=2;"},
+		{"stroke-width:5px,stroke:#0f0,opacity:0.5", "strokeColor=#0f0;strokeWidth=5;opacity=50;"},
+	}
+
+	for _, tt := range tests {
+		if got := ganttTodayStyle(tt.marker); got != tt.expected {
+			t.Errorf("ganttTodayStyle(%q) = %q, want %q", tt.marker, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatGanttDate(t *testing.T) {
+	date := time.Date(2024, time.March, 5, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"%Y-%m-%d", "2024-03-05"},
+		{"%d/%m", "05/03"},
+		{"%a %e %B", "Tue 5 March"},
+		{"%H:%M", "14:30"},
+		{"day %j, 100%%", "day 065, 100%"},
+	}
+
+	for _, tt := range tests {
+		if got := formatGanttDate(date, tt.format); got != tt.expected {
+			t.Errorf("formatGanttDate(%q) = %q, want %q", tt.format, got, tt.expected)
+		}
+	}
+}
@@ -3,6 +3,9 @@ package drawio
 import (
 	"encoding/xml"
 	"fmt"
+	"html"
+	"strconv"
+	"strings"
 	"mermaid2drawio/internal/mermaid"
 )
 
@@ -11,10 +14,12 @@ const (
 	EntityWidth      = 200.0
 	EntityHeight     = 30.0
 	AttributeHeight  = 20.0
-	EntitySpacing    = 300.0
+	EntityRankSpacing = 120.0 // between related entities, room for labels
+	EntitySpacing    = 40.0
+	EntityGroupSpacing = 80.0 // between groups of related entities
+	EntityRowWidth   = 1200.0 // groups wrap into a new row past this width
 	StartX           = 50.0
 	StartY           = 50.0
-	EntitiesPerRow   = 3
 )
 
 // Layout constants for sequence diagrams
@@ -23,6 +28,23 @@ const (
 	ParticipantHeight  = 50.0
 	ParticipantSpacing = 200.0
 	ParticipantY       = 50.0
+	ParticipantIconSize = 30.0 // height of actor and other UML symbols
+	MessageSpacing     = 50.0
+	NoteWidth          = 120.0
+	NoteHeight         = 36.0
+	NoteMargin         = 10.0
+	TextLineHeight     = 16.0 // extra height per "<br>" in labels
+	ActivationWidth    = 10.0
+	NumberBadgeSize    = 18.0
+	DestroyMarkerSize  = 20.0
+	BoxPadding         = 20.0 // around the participants of a box
+	BoxHeader          = 30.0 // room for the box title above the participants
+	SelfMessageWidth   = 40.0 // how far a self-message loops out to the right
+	SelfMessageHeight  = 30.0 // drop between a self-message leaving and returning
+	FramePadding       = 60.0 // between a lifeline and the fragment frame
+	FrameNest          = 10.0 // between nested frames
+	FrameHeaderHeight  = 20.0
+	FrameLabelWidth    = 60.0
 )
 
 // Draw.io model defaults
@@ -77,6 +99,7 @@ type MxCell struct {
 	Parent   string      `xml:"parent,attr,omitempty"`
 	Source   string      `xml:"source,attr,omitempty"`
 	Target   string      `xml:"target,attr,omitempty"`
+	Connectable string   `xml:"connectable,attr,omitempty"`
 	Geometry *MxGeometry `xml:"mxGeometry,omitempty"`
 }
 
@@ -85,7 +108,23 @@ type MxGeometry struct {
 	Y      *float64 `xml:"y,attr,omitempty"`
 	Width  *float64 `xml:"width,attr,omitempty"`
 	Height *float64 `xml:"height,attr,omitempty"`
+	Relative string `xml:"relative,attr,omitempty"`
 	As     string   `xml:"as,attr"`
+	Points []MxPoint `xml:"mxPoint"`
+	Array  *MxArray  `xml:"Array,omitempty"`
+}
+
+// MxPoint is an edge terminal point ("sourcePoint"/"targetPoint") or, inside
+// an MxArray, a waypoint.
+type MxPoint struct {
+	X  float64 `xml:"x,attr,omitempty"`
+	Y  float64 `xml:"y,attr,omitempty"`
+	As string  `xml:"as,attr,omitempty"`
+}
+
+type MxArray struct {
+	As     string    `xml:"as,attr"`
+	Points []MxPoint `xml:"mxPoint"`
 }
 
 func createBaseModel() *MxGraphModel {
@@ -124,101 +163,69 @@ func createDefaultCells() []MxCell {
 	}
 }
 
+// Options selects between alternative renderings. The zero value gives the
+// default output.
+type Options struct {
+	ERStyle ERStyle
+}
+
 func GenerateDrawIOXML(diagram mermaid.Diagram) (string, error) {
+	return GenerateDrawIOXMLWithOptions(diagram, Options{})
+}
+
+func GenerateDrawIOXMLWithOptions(diagram mermaid.Diagram, options Options) (string, error) {
 	switch d := diagram.(type) {
 	case *mermaid.SequenceDiagram:
 		return GenerateSequenceDrawIOXML(d)
 	case *mermaid.ERDiagram:
-		return GenerateERDrawIOXML(d)
+		return GenerateERDrawIOXMLWithStyle(d, options.ERStyle)
+	case *mermaid.FlowchartDiagram:
+		return GenerateFlowchartDrawIOXML(d)
+	case *mermaid.ClassDiagram:
+		return GenerateClassDrawIOXML(d)
+	case *mermaid.StateDiagram:
+		return GenerateStateDrawIOXML(d)
+	case *mermaid.GanttDiagram:
+		return GenerateGanttDrawIOXML(d)
+	case *mermaid.PieDiagram:
+		return GeneratePieDrawIOXML(d)
+	case *mermaid.MindmapDiagram:
+		return GenerateMindmapDrawIOXML(d)
 	default:
 		return "", fmt.Errorf("unsupported diagram type")
 	}
 }
 
 func GenerateERDrawIOXML(diagram *mermaid.ERDiagram) (string, error) {
+	return GenerateERDrawIOXMLWithStyle(diagram, ERListStyle)
+}
+
+// GenerateERDrawIOXMLWithStyle renders entities as attribute lists or as
+// draw.io tables.
+func GenerateERDrawIOXMLWithStyle(diagram *mermaid.ERDiagram, erStyle ERStyle) (string, error) {
 	model := createBaseModel()
 
 	cells := createDefaultCells()
 	cellID := 2
 	entityCells := make(map[string]string)
+	attributeCells := make(map[string]map[string]string) // entity -> attribute -> row cell
+	entities := make(map[string]mermaid.Entity)
 	
-	// Create entity tables
-	currentRow := 0
-	currentCol := 0
-	
+	// Create entity tables where the layout placed them
+	positions, sizes := erLayout(diagram, erStyle)
 	for _, entity := range diagram.Entities {
-		x := StartX + float64(currentCol)*EntitySpacing
-		y := StartY + float64(currentRow)*EntitySpacing
-		
-		totalHeight := EntityHeight + float64(len(entity.Attributes))*AttributeHeight
-		
-		// Create entity header
-		headerID := fmt.Sprintf("entity_header_%d", cellID)
-		entityCells[entity.Name] = headerID
-		
-		entityWidth := EntityWidth
-		headerCell := MxCell{
-			ID:     headerID,
-			Value:  entity.Name,
-			Style:  "swimlane;fontStyle=1;align=center;verticalAlign=middle;childLayout=stackLayout;horizontal=1;startSize=30;horizontalStack=0;resizeParent=1;resizeParentMax=0;resizeLast=0;collapsible=0;marginBottom=0;whiteSpace=wrap;html=1;",
-			Vertex: "1",
-			Parent: "1",
-			Geometry: &MxGeometry{
-				X:      &x,
-				Y:      &y,
-				Width:  &entityWidth,
-				Height: &totalHeight,
-				As:     "geometry",
-			},
-		}
-		cells = append(cells, headerCell)
-		cellID++
-		
-		// Create attributes
-		for i, attr := range entity.Attributes {
-			attrY := y + EntityHeight + float64(i)*AttributeHeight
-			attrID := fmt.Sprintf("attr_%d", cellID)
-			
-			// Format attribute text with constraints
-			attrText := fmt.Sprintf("%s: %s", attr.Name, attr.Type)
-			if attr.IsPK {
-				attrText = "🔑 " + attrText
-			}
-			if attr.IsFK {
-				attrText = "🔗 " + attrText
-			}
-			if attr.IsUnique {
-				attrText += " (UK)"
-			}
-			if attr.IsNotNull {
-				attrText += " (NN)"
-			}
-			
-			entityWidth := EntityWidth
-			attributeHeight := AttributeHeight
-			attrCell := MxCell{
-				ID:     attrID,
-				Value:  attrText,
-				Style:  "text;strokeColor=none;fillColor=none;align=left;verticalAlign=middle;spacingLeft=4;spacingRight=4;overflow=hidden;points=[[0,0.5],[1,0.5]];portConstraint=eastwest;rotatable=0;whiteSpace=wrap;html=1;",
-				Vertex: "1",
-				Parent: headerID,
-				Geometry: &MxGeometry{
-					Y:      &attrY,
-					Width:  &entityWidth,
-					Height: &attributeHeight,
-					As:     "geometry",
-				},
-			}
-			cells = append(cells, attrCell)
-			cellID++
-		}
+		x := positions[entity.Name].X
+		y := positions[entity.Name].Y
 		
-		// Update position for next entity
-		currentCol++
-		if currentCol >= EntitiesPerRow {
-			currentCol = 0
-			currentRow++
+		var entityCellList []MxCell
+		if erStyle == ERTableStyle {
+			entityCellList, attributeCells[entity.Name], cellID = erTableCells(entity, x, y, cellID)
+		} else {
+			entityCellList, attributeCells[entity.Name], cellID = erListCells(entity, x, y, cellID)
 		}
+		entityCells[entity.Name] = entityCellList[0].ID
+		entities[entity.Name] = entity
+		cells = append(cells, entityCellList...)
 	}
 	
 	// Create relationships
@@ -230,24 +237,30 @@ func GenerateERDrawIOXML(diagram *mermaid.ERDiagram) (string, error) {
 			continue // Skip if entity not found
 		}
 		
-		// Determine relationship style
-		style := "endArrow=none;html=1;rounded=0;entryX=0;entryY=0.5;entryDx=0;entryDy=0;exitX=1;exitY=0.5;exitDx=0;exitDy=0;"
+		// A foreign key referencing the other entity's primary key links
+		// the two attribute rows instead of the entities
+		fromAttr, toAttr := erKeyLink(relationship, entities)
+		if id, ok := attributeCells[relationship.From][fromAttr]; ok {
+			fromID = id
+		}
+		if id, ok := attributeCells[relationship.To][toAttr]; ok {
+			toID = id
+		}
+		
+		// Leave and enter on the facing sides of the two entities
+		style := "edgeStyle=entityRelationEdgeStyle;endArrow=none;html=1;rounded=0;"
+		style += erEdgeSides(relationship, positions, sizes)
 		
-		// Add cardinality indicators
-		switch relationship.Type {
-		case mermaid.OneToOne:
-			style += "startArrow=ERone;endArrow=ERone;"
-		case mermaid.OneToMany:
-			style += "startArrow=ERone;endArrow=ERmany;"
-		case mermaid.ManyToOne:
-			style += "startArrow=ERmany;endArrow=ERone;"
-		case mermaid.ManyToMany:
-			style += "startArrow=ERmany;endArrow=ERmany;"
+		// Add crow's-foot cardinality markers
+		fromEnd, toEnd := relationshipEnds(relationship)
+		style += fmt.Sprintf("startArrow=%s;startFill=0;endArrow=%s;endFill=0;", erMarker(fromEnd), erMarker(toEnd))
+		if relationship.Identification == mermaid.NonIdentifying {
+			style += "dashed=1;"
 		}
 		
 		relationshipCell := MxCell{
 			ID:     fmt.Sprintf("relationship_%d", cellID),
-			Value:  relationship.Label,
+			Value:  html.EscapeString(relationship.Label),
 			Style:  style,
 			Edge:   "1",
 			Parent: "1",
@@ -272,12 +285,231 @@ func GenerateERDrawIOXML(diagram *mermaid.ERDiagram) (string, error) {
 	return xml.Header + string(output), nil
 }
 
+// erListCells draws an entity as a swimlane with one text row per
+// attribute. The entity cell comes first; rows maps attribute names to their
+// row cells.
+func erListCells(entity mermaid.Entity, x, y float64, cellID int) ([]MxCell, map[string]string, int) {
+	totalHeight := EntityHeight + float64(len(entity.Attributes))*AttributeHeight
+	
+	// Create entity header
+	headerID := fmt.Sprintf("entity_header_%d", cellID)
+	
+	entityWidth := EntityWidth
+	headerCell := MxCell{
+		ID:     headerID,
+		Value:  html.EscapeString(entityLabel(entity)),
+		Style:  "swimlane;fontStyle=1;align=center;verticalAlign=middle;childLayout=stackLayout;horizontal=1;startSize=30;horizontalStack=0;resizeParent=1;resizeParentMax=0;resizeLast=0;collapsible=0;marginBottom=0;whiteSpace=wrap;html=1;",
+		Vertex: "1",
+		Parent: "1",
+		Geometry: &MxGeometry{
+			X:      &x,
+			Y:      &y,
+			Width:  &entityWidth,
+			Height: &totalHeight,
+			As:     "geometry",
+		},
+	}
+	cells := []MxCell{headerCell}
+	rows := make(map[string]string)
+	cellID++
+	
+	// Create attributes
+	for i, attr := range entity.Attributes {
+		attrY := EntityHeight + float64(i)*AttributeHeight
+		attrID := fmt.Sprintf("attr_%d", cellID)
+		
+		// Format attribute text with constraints
+		attrText := html.EscapeString(fmt.Sprintf("%s: %s", attr.Name, formatGeneric(attr.Type)))
+		if attr.IsPK {
+			attrText = "🔑 " + attrText
+		}
+		if attr.IsFK {
+			attrText = "🔗 " + attrText
+		}
+		if attr.IsUnique {
+			attrText += " (UK)"
+		}
+		if attr.IsNotNull {
+			attrText += " (NN)"
+		}
+		if attr.Comment != "" {
+			attrText += ` <i style="color:#808080;">` + html.EscapeString(attr.Comment) + "</i>"
+		}
+		
+		entityWidth := EntityWidth
+		attributeHeight := AttributeHeight
+		attrCell := MxCell{
+			ID:     attrID,
+			Value:  attrText,
+			Style:  "text;strokeColor=none;fillColor=none;align=left;verticalAlign=middle;spacingLeft=4;spacingRight=4;overflow=hidden;points=[[0,0.5],[1,0.5]];portConstraint=eastwest;rotatable=0;whiteSpace=wrap;html=1;",
+			Vertex: "1",
+			Parent: headerID,
+			Geometry: &MxGeometry{
+				Y:      &attrY,
+				Width:  &entityWidth,
+				Height: &attributeHeight,
+				As:     "geometry",
+			},
+		}
+		cells = append(cells, attrCell)
+		if _, exists := rows[attr.Name]; !exists {
+			rows[attr.Name] = attrID
+		}
+		cellID++
+	}
+	return cells, rows, cellID
+}
+
+// entityLabel returns the display name of an entity.
+func entityLabel(entity mermaid.Entity) string {
+	if entity.Alias != "" {
+		return entity.Alias
+	}
+	return entity.Name
+}
+
+// relationshipEnds returns the cardinalities of both ends, deriving
+// unspecified ones from the relationship type.
+func relationshipEnds(relationship mermaid.Relationship) (mermaid.Cardinality, mermaid.Cardinality) {
+	fromEnd, toEnd := relationship.FromEnd, relationship.ToEnd
+	fromMany := relationship.Type == mermaid.ManyToOne || relationship.Type == mermaid.ManyToMany
+	toMany := relationship.Type == mermaid.OneToMany || relationship.Type == mermaid.ManyToMany
+	if fromEnd == mermaid.UnspecifiedCardinality {
+		fromEnd = mermaid.ExactlyOne
+		if fromMany {
+			fromEnd = mermaid.ZeroOrMore
+		}
+	}
+	if toEnd == mermaid.UnspecifiedCardinality {
+		toEnd = mermaid.ExactlyOne
+		if toMany {
+			toEnd = mermaid.ZeroOrMore
+		}
+	}
+	return fromEnd, toEnd
+}
+
+// erMarker returns the draw.io crow's-foot arrow of a cardinality.
+func erMarker(cardinality mermaid.Cardinality) string {
+	switch cardinality {
+	case mermaid.ZeroOrOne:
+		return "ERzeroToOne"
+	case mermaid.ZeroOrMore:
+		return "ERzeroToMany"
+	case mermaid.OneOrMore:
+		return "ERoneToMany"
+	default:
+		return "ERmandOne"
+	}
+}
+
 func GenerateSequenceDrawIOXML(diagram *mermaid.SequenceDiagram) (string, error) {
 	model := createBaseModel()
 
 	cells := createDefaultCells()
 	cellID := 2
 	participantCells := make(map[string]string)
+	participantCenters := make(map[string]float64)
+	participantShapes := make(map[string]layoutNode)
+	createdAt := make(map[string]int)
+	for _, participant := range diagram.Participants {
+		if participant.Created {
+			createdAt[participant.Name] = participant.CreatedAt
+		}
+	}
+	
+	events := sequenceEvents(diagram)
+	timeline := newSequenceTimeline(diagram, events)
+	messageRows := make(map[int]int)
+	for row, event := range events {
+		if event.Kind == mermaid.MessageEvent {
+			messageRows[event.Index] = row
+		}
+	}
+	
+	// Create participant boxes behind their participants' columns; the
+	// participants become children of their box
+	boxCells := make(map[string]string)
+	boxOrigins := make(map[string]layoutPosition)
+	columns := make(map[string]int)
+	for i, participant := range diagram.Participants {
+		columns[participant.Name] = i
+		participantCenters[participant.Name] = StartX + float64(i)*ParticipantSpacing + ParticipantWidth/2
+	}
+	for _, box := range diagram.Boxes {
+		first, last := -1, -1
+		for _, name := range box.Participants {
+			column, ok := columns[name]
+			if !ok {
+				continue
+			}
+			if first < 0 || column < first {
+				first = column
+			}
+			if last < 0 || column > last {
+				last = column
+			}
+		}
+		if first < 0 {
+			continue // Skip boxes without known participants
+		}
+		
+		x := StartX + float64(first)*ParticipantSpacing - BoxPadding
+		y := ParticipantY - BoxHeader
+		width := float64(last-first)*ParticipantSpacing + ParticipantWidth + 2*BoxPadding
+		height := timeline.bottom + BoxPadding - y
+		boxID := fmt.Sprintf("box_%d", cellID)
+		for _, name := range box.Participants {
+			if _, ok := columns[name]; ok && boxCells[name] == "" {
+				boxCells[name] = boxID
+				boxOrigins[name] = layoutPosition{X: x, Y: y}
+			}
+		}
+		cells = append(cells, MxCell{
+			ID:     boxID,
+			Value:  html.EscapeString(box.Title),
+			Style:  "rounded=0;whiteSpace=wrap;html=1;verticalAlign=top;fontStyle=1;strokeColor=#666666;container=1;collapsible=0;" + fillStyle(box.Color),
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &y,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
+	}
+	
+	// Create highlight regions behind the rows they enclose, outer rects
+	// first
+	spans := fragmentSpans(diagram, events, participantCenters)
+	for i, block := range diagram.Blocks {
+		span := spans[i]
+		if block.Kind != mermaid.RectBlock || !span.ok || block.End < block.Start {
+			continue
+		}
+		
+		x := span.left
+		y := timeline.rowY(block.Start) - FrameHeaderHeight/2
+		width := span.right - span.left
+		height := timeline.rowY(block.End) - FrameHeaderHeight/2 - y
+		cells = append(cells, MxCell{
+			ID:     fmt.Sprintf("rect_%d", cellID),
+			Style:  "rounded=0;whiteSpace=wrap;html=1;strokeColor=none;" + fillStyle(block.Color),
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &y,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
+	}
 	
 	// Create participant rectangles (actors)
 	
@@ -286,17 +518,34 @@ func GenerateSequenceDrawIOXML(diagram *mermaid.SequenceDiagram) (string, error)
 		id := fmt.Sprintf("participant_%d", cellID)
 		participantCells[participant.Name] = id
 		
+		// Symbols smaller than the box are centred at its top, with the
+		// name below them
+		style, participantWidth, participantHeight := participantShape(participant.Kind)
+		shapeX := x + (ParticipantWidth-participantWidth)/2
 		participantY := ParticipantY
-		participantWidth := ParticipantWidth
-		participantHeight := ParticipantHeight
+		if participant.Created {
+			// Created participants appear beside their creating message
+			participantY = timeline.rowY(participant.CreatedAt) - ParticipantHeight/2
+		}
+		participantShapes[participant.Name] = layoutNode{ID: id, Width: participantWidth, Height: participantHeight}
+		
+		// Inside a box, geometry is relative to the box
+		parentID := "1"
+		origin := layoutPosition{}
+		if boxID, ok := boxCells[participant.Name]; ok {
+			parentID = boxID
+			origin = boxOrigins[participant.Name]
+			shapeX -= origin.X
+			participantY -= origin.Y
+		}
 		cell := MxCell{
 			ID:     id,
 			Value:  participant.Alias,
-			Style:  "rounded=0;whiteSpace=wrap;html=1;",
+			Style:  style,
 			Vertex: "1",
-			Parent: "1",
+			Parent: parentID,
 			Geometry: &MxGeometry{
-				X:      &x,
+				X:      &shapeX,
 				Y:      &participantY,
 				Width:  &participantWidth,
 				Height: &participantHeight,
@@ -306,60 +555,670 @@ func GenerateSequenceDrawIOXML(diagram *mermaid.SequenceDiagram) (string, error)
 		cells = append(cells, cell)
 		cellID++
 		
-		// Add lifeline (vertical line)
+		// Add lifeline (vertical line) from the box down to the last row,
+		// or to the destruction marker
+		center := participantCenters[participant.Name] - origin.X
+		lifelineEnd := timeline.bottom - origin.Y
+		if participant.Destroyed {
+			lifelineEnd = timeline.rowY(participant.DestroyedAt) - origin.Y
+		}
 		lifelineCell := MxCell{
 			ID:     fmt.Sprintf("lifeline_%d", cellID),
-			Style:  "endArrow=none;dashed=1;html=1;",
+			Style:  fmt.Sprintf("endArrow=none;dashed=1;html=1;exitX=0.5;exitY=1;exitDx=0;exitDy=%g;", ParticipantHeight-participantHeight),
 			Edge:   "1",
-			Parent: "1",
+			Parent: parentID,
+			Source: id,
 			Geometry: &MxGeometry{
-				As: "geometry",
+				Relative: "1",
+				As:       "geometry",
+				Points: []MxPoint{
+					{X: center, Y: participantY + ParticipantHeight, As: "sourcePoint"},
+					{X: center, Y: lifelineEnd, As: "targetPoint"},
+				},
 			},
 		}
 		cells = append(cells, lifelineCell)
 		cellID++
+		
+		if participant.Destroyed {
+			markerX := center - DestroyMarkerSize/2
+			markerY := lifelineEnd - DestroyMarkerSize/2
+			markerSize := DestroyMarkerSize
+			cells = append(cells, MxCell{
+				ID:     fmt.Sprintf("destroy_%d", cellID),
+				Style:  "shape=umlDestroy;whiteSpace=wrap;html=1;strokeWidth=3;",
+				Vertex: "1",
+				Parent: parentID,
+				Geometry: &MxGeometry{
+					X:      &markerX,
+					Y:      &markerY,
+					Width:  &markerSize,
+					Height: &markerSize,
+					As:     "geometry",
+				},
+			})
+			cellID++
+		}
 	}
 	
-	// Create message arrows
-	for _, message := range diagram.Messages {
-		fromID := participantCells[message.From]
-		toID := participantCells[message.To]
+	// Create combined fragment frames, outer blocks first
+	for i, block := range diagram.Blocks {
+		span := spans[i]
+		if block.Kind == mermaid.RectBlock || !span.ok || block.End < block.Start {
+			continue
+		}
 		
-		if fromID == "" || toID == "" {
+		x := span.left
+		y := timeline.rowY(block.Start) - FrameHeaderHeight/2
+		width := span.right - span.left
+		height := timeline.rowY(block.End) - FrameHeaderHeight/2 - y
+		cells = append(cells, MxCell{
+			ID:     fmt.Sprintf("fragment_%d", cellID),
+			Value:  fragmentLabel(block.Kind),
+			Style:  fmt.Sprintf("shape=umlFrame;whiteSpace=wrap;html=1;pointerEvents=0;fillColor=none;width=%g;height=%g;", FrameLabelWidth, FrameHeaderHeight),
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &y,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
+		
+		for j, section := range block.Sections {
+			sectionY := timeline.rowY(section.Start) - FrameHeaderHeight/2
+			labelX := x + FrameNest
+			if j == 0 {
+				labelX = x + FrameLabelWidth + FrameNest
+			} else {
+				// Dashed separator between sections
+				lineY := sectionY - FrameNest/2
+				lineHeight := FrameNest
+				cells = append(cells, MxCell{
+					ID:     fmt.Sprintf("separator_%d", cellID),
+					Style:  "line;strokeWidth=1;dashed=1;html=1;fillColor=none;",
+					Vertex: "1",
+					Parent: "1",
+					Geometry: &MxGeometry{
+						X:      &x,
+						Y:      &lineY,
+						Width:  &width,
+						Height: &lineHeight,
+						As:     "geometry",
+					},
+				})
+				cellID++
+			}
+			if section.Label == "" {
+				continue
+			}
+			
+			labelWidth := x + width - labelX
+			labelHeight := FrameHeaderHeight
+			cells = append(cells, MxCell{
+				ID:     fmt.Sprintf("condition_%d", cellID),
+				Value:  "[" + section.Label + "]",
+				Style:  "text;html=1;align=left;verticalAlign=middle;strokeColor=none;fillColor=none;fontStyle=1;",
+				Vertex: "1",
+				Parent: "1",
+				Geometry: &MxGeometry{
+					X:      &labelX,
+					Y:      &sectionY,
+					Width:  &labelWidth,
+					Height: &labelHeight,
+					As:     "geometry",
+				},
+			})
+			cellID++
+		}
+	}
+	
+	// Create activation bars on the lifelines
+	bars := make([]activationBar, 0, len(diagram.Activations))
+	for _, activation := range diagram.Activations {
+		center, ok := participantCenters[activation.Participant]
+		if !ok {
 			continue // Skip if participant not found
 		}
 		
-		// Determine arrow style based on message type
-		var style string
-		switch message.Type {
-		case mermaid.SolidArrow:
-			style = "endArrow=classic;html=1;"
-		case mermaid.DashedArrow:
-			style = "endArrow=classic;html=1;dashed=1;"
-		case mermaid.SolidArrowWithX:
-			style = "endArrow=block;endFill=1;html=1;"
-		case mermaid.DashedArrowWithX:
-			style = "endArrow=block;endFill=1;html=1;dashed=1;"
-		default:
-			style = "endArrow=classic;html=1;"
+		x := center - ActivationWidth/2 + float64(activation.Level)*ActivationWidth/2
+		// Bars opened or closed by a self-message start or end where the
+		// loop returns to the lifeline
+		y := timeline.rowY(activation.Start)
+		if activation.Start < len(events) && isSelfMessage(diagram, events[activation.Start]) {
+			y += SelfMessageHeight
+		}
+		bottom := timeline.rowY(activation.End)
+		if activation.End < len(events) && isSelfMessage(diagram, events[activation.End]) {
+			bottom += SelfMessageHeight
+		}
+		width := ActivationWidth
+		height := bottom - y
+		if height <= 0 {
+			height = MessageSpacing / 2
+		}
+		
+		id := fmt.Sprintf("activation_%d", cellID)
+		bars = append(bars, activationBar{activation: activation, id: id, x: x, y: y, height: height})
+		cells = append(cells, MxCell{
+			ID:     id,
+			Style:  "html=1;points=[];perimeter=orthogonalPerimeter;outlineConnect=0;targetShapes=umlLifeline;portConstraint=eastwest;",
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &y,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
+	}
+	
+	// Create message arrows at the row of each message. Ends on an active
+	// bar attach to the side facing the other participant; other ends are
+	// free points on the lifeline.
+	for i, message := range diagram.Messages {
+		fromX, fromKnown := participantCenters[message.From]
+		toX, toKnown := participantCenters[message.To]
+		
+		if !fromKnown || !toKnown {
+			continue // Skip if participant not found
 		}
 		
+		row := messageRows[i]
+		y := timeline.rowY(row)
+		if message.From == message.To {
+			cells = append(cells, selfMessageCell(fmt.Sprintf("message_%d", cellID), message, row, y, fromX, bars))
+			cellID++
+			if message.Numbered {
+				cells = append(cells, numberBadgeCell(fmt.Sprintf("number_%d", cellID), message.Number, fromX, y))
+				cellID++
+			}
+			continue
+		}
+		
+		rightwards := toX >= fromX
+		var fromID, toID, anchors string
+		if bar := innermostBar(bars, message.From, row); bar != nil {
+			fromID = bar.id
+			fromX = bar.side(rightwards)
+			anchors += fmt.Sprintf("exitX=%s;exitY=%g;exitDx=0;exitDy=0;", sideX(rightwards), bar.offset(y))
+		}
+		if bar := innermostBar(bars, message.To, row); bar != nil {
+			toID = bar.id
+			toX = bar.side(!rightwards)
+			anchors += fmt.Sprintf("entryX=%s;entryY=%g;entryDx=0;entryDy=0;", sideX(!rightwards), bar.offset(y))
+		}
+		
+		// The creating message ends at the side of the new participant
+		if created, ok := createdAt[message.To]; ok && created == row && toID == "" {
+			shape := participantShapes[message.To]
+			toID = shape.ID
+			if rightwards {
+				toX -= shape.Width / 2
+			} else {
+				toX += shape.Width / 2
+			}
+			anchors += fmt.Sprintf("entryX=%s;entryY=%g;entryDx=0;entryDy=0;", sideX(!rightwards), ParticipantHeight/2/shape.Height)
+		}
+		
+		style := messageStyle(message.Type)
+		
 		messageCell := MxCell{
 			ID:     fmt.Sprintf("message_%d", cellID),
 			Value:  message.Text,
-			Style:  style,
+			Style:  style + "verticalAlign=bottom;" + anchors,
 			Edge:   "1",
 			Parent: "1",
 			Source: fromID,
 			Target: toID,
 			Geometry: &MxGeometry{
-				As: "geometry",
+				Relative: "1",
+				As:       "geometry",
+				Points: []MxPoint{
+					{X: fromX, Y: y, As: "sourcePoint"},
+					{X: toX, Y: y, As: "targetPoint"},
+				},
 			},
 		}
 		cells = append(cells, messageCell)
 		cellID++
+		
+		// Autonumbered messages carry a badge where they start
+		if message.Numbered {
+			cells = append(cells, numberBadgeCell(fmt.Sprintf("number_%d", cellID), message.Number, fromX, y))
+			cellID++
+		}
+	}
+	
+	// Create notes in the timeline row of their event
+	for row, event := range events {
+		if event.Kind != mermaid.NoteEvent {
+			continue
+		}
+		note := diagram.Notes[event.Index]
+		x, width, ok := noteSpan(note, participantCenters)
+		if !ok {
+			continue // Skip if participant not found
+		}
+		
+		height := noteHeight(note)
+		y := timeline.noteY(row) - height/2
+		cells = append(cells, MxCell{
+			ID:     fmt.Sprintf("note_%d", cellID),
+			Value:  note.Text,
+			Style:  "shape=note;whiteSpace=wrap;html=1;backgroundOutline=1;size=14;fillColor=#fff2cc;strokeColor=#d6b656;",
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &y,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
 	}
 	
 	model.Root.MxCells = cells
 	return generateXMLOutput(model)
+}
+
+// sequenceEvents returns the timeline rows of a diagram. Diagrams built
+// without events are treated as a plain list of messages.
+func sequenceEvents(diagram *mermaid.SequenceDiagram) []mermaid.SequenceEvent {
+	if len(diagram.Events) > 0 {
+		return diagram.Events
+	}
+	events := make([]mermaid.SequenceEvent, len(diagram.Messages))
+	for i := range diagram.Messages {
+		events[i] = mermaid.SequenceEvent{Kind: mermaid.MessageEvent, Index: i}
+	}
+	return events
+}
+
+// fillStyle returns the fill of a Mermaid color; the zero color is no fill.
+func fillStyle(color mermaid.Color) string {
+	if color.Hex == "" {
+		return "fillColor=none;"
+	}
+	style := "fillColor=" + color.Hex + ";"
+	if color.Opacity < 100 {
+		style += fmt.Sprintf("fillOpacity=%d;", color.Opacity)
+	}
+	return style
+}
+
+// participantShape returns the style and size of a participant symbol.
+func participantShape(kind mermaid.ParticipantKind) (string, float64, float64) {
+	const iconLabel = "verticalLabelPosition=bottom;verticalAlign=top;html=1;outlineConnect=0;"
+	switch kind {
+	case mermaid.ActorParticipant:
+		return "shape=umlActor;" + iconLabel, ParticipantIconSize / 2, ParticipantIconSize
+	case mermaid.BoundaryParticipant:
+		return "shape=umlBoundary;whiteSpace=wrap;" + iconLabel, ParticipantIconSize * 4 / 3, ParticipantIconSize
+	case mermaid.ControlParticipant:
+		return "ellipse;shape=umlControl;whiteSpace=wrap;" + iconLabel, ParticipantIconSize * 7 / 8, ParticipantIconSize
+	case mermaid.EntityParticipant:
+		return "ellipse;shape=umlEntity;whiteSpace=wrap;" + iconLabel, ParticipantIconSize, ParticipantIconSize
+	case mermaid.DatabaseParticipant:
+		return "shape=cylinder3;whiteSpace=wrap;html=1;boundedLbl=1;backgroundOutline=1;size=10;", ParticipantWidth, ParticipantHeight
+	case mermaid.CollectionsParticipant:
+		return "shape=mxgraph.basic.layered_rect;dx=6;outlineConnect=0;whiteSpace=wrap;html=1;", ParticipantWidth, ParticipantHeight
+	case mermaid.QueueParticipant:
+		return "shape=cylinder3;whiteSpace=wrap;html=1;boundedLbl=1;backgroundOutline=1;size=10;direction=south;", ParticipantWidth, ParticipantHeight
+	default:
+		return "rounded=0;whiteSpace=wrap;html=1;", ParticipantWidth, ParticipantHeight
+	}
+}
+
+// messageStyle returns the arrow style of a message type. Lines without an
+// arrowhead, filled arrowheads, crosses (lost messages) and open async
+// arrowheads follow Mermaid's rendering.
+func messageStyle(messageType mermaid.MessageType) string {
+	switch messageType {
+	case mermaid.SolidLine:
+		return "endArrow=none;html=1;"
+	case mermaid.DashedLine:
+		return "endArrow=none;html=1;dashed=1;"
+	case mermaid.SolidArrow:
+		return "endArrow=block;endFill=1;html=1;"
+	case mermaid.DashedArrow:
+		return "endArrow=block;endFill=1;html=1;dashed=1;"
+	case mermaid.SolidCross:
+		return "endArrow=cross;endFill=0;html=1;"
+	case mermaid.DashedCross:
+		return "endArrow=cross;endFill=0;html=1;dashed=1;"
+	case mermaid.SolidAsync:
+		return "endArrow=open;endFill=0;html=1;"
+	case mermaid.DashedAsync:
+		return "endArrow=open;endFill=0;html=1;dashed=1;"
+	case mermaid.SolidBidirectional:
+		return "startArrow=block;startFill=1;endArrow=block;endFill=1;html=1;"
+	case mermaid.DashedBidirectional:
+		return "startArrow=block;startFill=1;endArrow=block;endFill=1;html=1;dashed=1;"
+	default:
+		return "endArrow=classic;html=1;"
+	}
+}
+
+// selfMessageCell draws a message to the sender's own lifeline as a loop out
+// to the right and back, SelfMessageHeight lower. A bar opened by the message
+// receives the returning arrow, while the loop leaves the bar around it.
+func selfMessageCell(id string, message mermaid.Message, row int, y, center float64, bars []activationBar) MxCell {
+	fromX, toX := center, center
+	var fromID, toID, anchors string
+	toBar := innermostBar(bars, message.From, row)
+	fromBar := toBar
+	if toBar != nil && toBar.activation.Start == row && message.Activate {
+		fromBar = enclosingBar(bars, toBar, row)
+	}
+	if fromBar != nil {
+		fromID = fromBar.id
+		fromX = fromBar.side(true)
+		anchors += fmt.Sprintf("exitX=1;exitY=%g;exitDx=0;exitDy=0;", fromBar.offset(y))
+	}
+	if toBar != nil {
+		toID = toBar.id
+		toX = toBar.side(true)
+		anchors += fmt.Sprintf("entryX=1;entryY=%g;entryDx=0;entryDy=0;", toBar.offset(y+SelfMessageHeight))
+	}
+	
+	loopX := max(fromX, toX) + SelfMessageWidth
+	return MxCell{
+		ID:     id,
+		Value:  message.Text,
+		Style:  messageStyle(message.Type) + "align=left;spacingLeft=4;rounded=0;" + anchors,
+		Edge:   "1",
+		Parent: "1",
+		Source: fromID,
+		Target: toID,
+		Geometry: &MxGeometry{
+			Relative: "1",
+			As:       "geometry",
+			Points: []MxPoint{
+				{X: fromX, Y: y, As: "sourcePoint"},
+				{X: toX, Y: y + SelfMessageHeight, As: "targetPoint"},
+			},
+			Array: &MxArray{
+				As: "points",
+				Points: []MxPoint{
+					{X: loopX, Y: y},
+					{X: loopX, Y: y + SelfMessageHeight},
+				},
+			},
+		},
+	}
+}
+
+// numberBadgeCell draws an autonumber circle centred on (x, y).
+func numberBadgeCell(id string, number int, x, y float64) MxCell {
+	badgeX := x - NumberBadgeSize/2
+	badgeY := y - NumberBadgeSize/2
+	size := NumberBadgeSize
+	return MxCell{
+		ID:     id,
+		Value:  strconv.Itoa(number),
+		Style:  "ellipse;whiteSpace=wrap;html=1;aspect=fixed;fillColor=#000000;strokeColor=none;fontColor=#FFFFFF;fontSize=10;fontStyle=1;",
+		Vertex: "1",
+		Parent: "1",
+		Geometry: &MxGeometry{
+			X:      &badgeX,
+			Y:      &badgeY,
+			Width:  &size,
+			Height: &size,
+			As:     "geometry",
+		},
+	}
+}
+
+// activationBar is an emitted activation cell and its vertical extent.
+type activationBar struct {
+	activation mermaid.Activation
+	id         string
+	x          float64
+	y          float64
+	height     float64
+}
+
+// offset returns the relative position of y along the bar.
+func (b *activationBar) offset(y float64) float64 {
+	return (y - b.y) / b.height
+}
+
+// side returns the x of the bar's right or left edge.
+func (b *activationBar) side(right bool) float64 {
+	if right {
+		return b.x + ActivationWidth
+	}
+	return b.x
+}
+
+// innermostBar returns the most deeply nested bar of participant that is
+// active at row, or nil.
+func innermostBar(bars []activationBar, participant string, row int) *activationBar {
+	var innermost *activationBar
+	for i := range bars {
+		bar := &bars[i]
+		if bar.activation.Participant != participant || row < bar.activation.Start || row > bar.activation.End {
+			continue
+		}
+		if innermost == nil || bar.activation.Level > innermost.activation.Level {
+			innermost = bar
+		}
+	}
+	return innermost
+}
+
+// enclosingBar returns the innermost bar of the same participant that
+// encloses bar at row, or nil.
+func enclosingBar(bars []activationBar, bar *activationBar, row int) *activationBar {
+	var enclosing *activationBar
+	for i := range bars {
+		candidate := &bars[i]
+		if candidate.activation.Participant != bar.activation.Participant || candidate.activation.Level >= bar.activation.Level {
+			continue
+		}
+		if row < candidate.activation.Start || row > candidate.activation.End {
+			continue
+		}
+		if enclosing == nil || candidate.activation.Level > enclosing.activation.Level {
+			enclosing = candidate
+		}
+	}
+	return enclosing
+}
+
+func sideX(right bool) string {
+	if right {
+		return "1"
+	}
+	return "0"
+}
+
+// fragmentSpan is the horizontal extent of a combined fragment frame.
+type fragmentSpan struct {
+	left  float64
+	right float64
+	ok    bool
+}
+
+func (s *fragmentSpan) include(left, right float64) {
+	if !s.ok || left < s.left {
+		s.left = left
+	}
+	if !s.ok || right > s.right {
+		s.right = right
+	}
+	s.ok = true
+}
+
+// fragmentSpans sizes every block to enclose the messages and notes in its
+// rows. Nested blocks come after their parent in diagram.Blocks, so walking
+// backwards finishes each child before it widens its parent.
+func fragmentSpans(diagram *mermaid.SequenceDiagram, events []mermaid.SequenceEvent, centers map[string]float64) []fragmentSpan {
+	spans := make([]fragmentSpan, len(diagram.Blocks))
+	for i := len(diagram.Blocks) - 1; i >= 0; i-- {
+		block := diagram.Blocks[i]
+		span := &spans[i]
+		for row := block.Start + 1; row < block.End && row < len(events); row++ {
+			event := events[row]
+			switch event.Kind {
+			case mermaid.MessageEvent:
+				message := diagram.Messages[event.Index]
+				for _, name := range []string{message.From, message.To} {
+					if center, ok := centers[name]; ok {
+						span.include(center-FramePadding, center+FramePadding)
+					}
+				}
+			case mermaid.NoteEvent:
+				if x, width, ok := noteSpan(diagram.Notes[event.Index], centers); ok {
+					span.include(x-FrameNest, x+width+FrameNest)
+				}
+			}
+		}
+		
+		// Empty blocks stretch across every participant
+		if !span.ok {
+			for _, center := range centers {
+				span.include(center-FramePadding, center+FramePadding)
+			}
+		}
+		if span.ok && block.Parent >= 0 && block.Parent < i {
+			spans[block.Parent].include(span.left-FrameNest, span.right+FrameNest)
+		}
+	}
+	return spans
+}
+
+func fragmentLabel(kind mermaid.BlockKind) string {
+	switch kind {
+	case mermaid.AltBlock:
+		return "alt"
+	case mermaid.OptBlock:
+		return "opt"
+	case mermaid.ParBlock:
+		return "par"
+	case mermaid.CriticalBlock:
+		return "critical"
+	case mermaid.BreakBlock:
+		return "break"
+	default:
+		return "loop"
+	}
+}
+
+// sequenceTimeline holds the y of every timeline row. Messages are drawn at
+// their row's y with the label above; rows grow with multi-line labels.
+type sequenceTimeline struct {
+	rows    []float64
+	heights []float64
+	bottom  float64
+}
+
+func newSequenceTimeline(diagram *mermaid.SequenceDiagram, events []mermaid.SequenceEvent) *sequenceTimeline {
+	timeline := &sequenceTimeline{
+		rows:    make([]float64, len(events)),
+		heights: make([]float64, len(events)),
+	}
+	
+	y := ParticipantY + ParticipantHeight
+	for row, event := range events {
+		height := MessageSpacing
+		switch event.Kind {
+		case mermaid.MessageEvent:
+			height += textLines(diagram.Messages[event.Index].Text) * TextLineHeight
+		case mermaid.NoteEvent:
+			height = max(height, noteHeight(diagram.Notes[event.Index])+NoteMargin)
+		}
+		y += height
+		timeline.rows[row] = y
+		timeline.heights[row] = height
+		
+		// A self-message returns below its row
+		if isSelfMessage(diagram, event) {
+			y += SelfMessageHeight
+		}
+	}
+	timeline.bottom = y + MessageSpacing
+	return timeline
+}
+
+// isSelfMessage reports whether event is a message to its own sender.
+func isSelfMessage(diagram *mermaid.SequenceDiagram, event mermaid.SequenceEvent) bool {
+	if event.Kind != mermaid.MessageEvent {
+		return false
+	}
+	message := diagram.Messages[event.Index]
+	return message.From == message.To
+}
+
+// rowY returns the y of a row. Rows past the end continue the last spacing,
+// which keeps bars and frames of hand-built diagrams drawable.
+func (t *sequenceTimeline) rowY(row int) float64 {
+	if row < len(t.rows) {
+		return t.rows[row]
+	}
+	last := ParticipantY + ParticipantHeight
+	if len(t.rows) > 0 {
+		last = t.rows[len(t.rows)-1]
+	}
+	return last + float64(row-len(t.rows)+1)*MessageSpacing
+}
+
+// noteY returns the vertical centre of a note row; taller notes extend
+// upwards into the extra height of their row.
+func (t *sequenceTimeline) noteY(row int) float64 {
+	if row < len(t.heights) {
+		return t.rowY(row) - (t.heights[row]-MessageSpacing)/2
+	}
+	return t.rowY(row)
+}
+
+// textLines counts the extra lines a "<br>" separated label needs.
+func textLines(text string) float64 {
+	return float64(strings.Count(strings.ToLower(text), "<br"))
+}
+
+func noteHeight(note mermaid.Note) float64 {
+	return NoteHeight + textLines(note.Text)*TextLineHeight
+}
+
+// noteSpan returns the horizontal extent of a note. Notes over several
+// participants stretch across the boxes of the outermost ones.
+func noteSpan(note mermaid.Note, centers map[string]float64) (float64, float64, bool) {
+	left, right := 0.0, 0.0
+	for i, name := range note.Participants {
+		center, ok := centers[name]
+		if !ok {
+			return 0, 0, false
+		}
+		if i == 0 || center < left {
+			left = center
+		}
+		if i == 0 || center > right {
+			right = center
+		}
+	}
+	if len(note.Participants) == 0 {
+		return 0, 0, false
+	}
+	
+	switch note.Placement {
+	case mermaid.NoteLeftOf:
+		return left - NoteMargin - NoteWidth, NoteWidth, true
+	case mermaid.NoteRightOf:
+		return right + NoteMargin, NoteWidth, true
+	default:
+		if left == right {
+			return left - NoteWidth/2, NoteWidth, true
+		}
+		return left - ParticipantWidth/2, right - left + ParticipantWidth, true
+	}
 }
\ No newline at end of file
@@ -13,8 +13,8 @@ func TestGenerateDrawIOXML(t *testing.T) {
 			{Name: "B", Alias: "Bob"},
 		},
 		Messages: []mermaid.Message{
-			{From: "A", To: "B", Text: "Hello", Type: mermaid.SolidArrow},
-			{From: "B", To: "A", Text: "Hi", Type: mermaid.DashedArrow},
+			{From: "A", To: "B", Text: "Hello", Type: mermaid.SolidLine},
+			{From: "B", To: "A", Text: "Hi", Type: mermaid.DashedLine},
 		},
 	}
 	
@@ -69,35 +69,41 @@ func TestGenerateDrawIOXMLEmpty(t *testing.T) {
 }
 
 func TestMessageTypeStyles(t *testing.T) {
-	diagram := &mermaid.SequenceDiagram{
-		Participants: []mermaid.Participant{
-			{Name: "A", Alias: "A"},
-			{Name: "B", Alias: "B"},
-		},
-		Messages: []mermaid.Message{
-			{From: "A", To: "B", Text: "solid", Type: mermaid.SolidArrow},
-			{From: "A", To: "B", Text: "dashed", Type: mermaid.DashedArrow},
-			{From: "A", To: "B", Text: "solidX", Type: mermaid.SolidArrowWithX},
-			{From: "A", To: "B", Text: "dashedX", Type: mermaid.DashedArrowWithX},
-		},
-	}
-	
-	xml, err := GenerateDrawIOXML(diagram)
-	if err != nil {
-		t.Fatalf("Unexpected error: %v", err)
-	}
-	
-	// Check different arrow styles are present
-	if !strings.Contains(xml, "endArrow=classic;html=1;") {
-		t.Error("Should contain solid arrow style")
-	}
-	
-	if !strings.Contains(xml, "dashed=1") {
-		t.Error("Should contain dashed arrow style")
-	}
-	
-	if !strings.Contains(xml, "endArrow=block;endFill=1") {
-		t.Error("Should contain block arrow style")
+	tests := []struct {
+		messageType mermaid.MessageType
+		style       string
+	}{
+		{mermaid.SolidLine, "endArrow=none;html=1;"},
+		{mermaid.DashedLine, "endArrow=none;html=1;dashed=1;"},
+		{mermaid.SolidArrow, "endArrow=block;endFill=1;html=1;"},
+		{mermaid.DashedArrow, "endArrow=block;endFill=1;html=1;dashed=1;"},
+		{mermaid.SolidCross, "endArrow=cross;endFill=0;html=1;"},
+		{mermaid.DashedCross, "endArrow=cross;endFill=0;html=1;dashed=1;"},
+		{mermaid.SolidAsync, "endArrow=open;endFill=0;html=1;"},
+		{mermaid.DashedAsync, "endArrow=open;endFill=0;html=1;dashed=1;"},
+		{mermaid.SolidBidirectional, "startArrow=block;startFill=1;endArrow=block;endFill=1;html=1;"},
+		{mermaid.DashedBidirectional, "startArrow=block;startFill=1;endArrow=block;endFill=1;html=1;dashed=1;"},
+	}
+	
+	for _, tt := range tests {
+		diagram := &mermaid.SequenceDiagram{
+			Participants: []mermaid.Participant{
+				{Name: "A", Alias: "A"},
+				{Name: "B", Alias: "B"},
+			},
+			Messages: []mermaid.Message{
+				{From: "A", To: "B", Text: "message", Type: tt.messageType},
+			},
+		}
+		
+		xml, err := GenerateDrawIOXML(diagram)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		
+		if !strings.Contains(xml, `style="`+tt.style) {
+			t.Errorf("Message type %v should use style %q", tt.messageType, tt.style)
+		}
 	}
 }
 
@@ -171,7 +177,7 @@ func TestGenerateERDrawIOXML(t *testing.T) {
 		t.Error("XML should contain relationship label")
 	}
 	
-	if !strings.Contains(xml, "startArrow=ERone;endArrow=ERmany") {
+	if !strings.Contains(xml, "startArrow=ERmandOne;startFill=0;endArrow=ERzeroToMany;endFill=0;") {
 		t.Error("XML should contain ER relationship arrows")
 	}
 }
@@ -268,19 +274,91 @@ func TestERDiagramRelationshipTypes(t *testing.T) {
 	}
 	
 	// Check different relationship types
-	if !strings.Contains(xml, "startArrow=ERone;endArrow=ERone") {
+	if !strings.Contains(xml, "startArrow=ERmandOne;startFill=0;endArrow=ERmandOne;") {
 		t.Error("Should contain one-to-one relationship style")
 	}
 	
-	if !strings.Contains(xml, "startArrow=ERmany;endArrow=ERone") {
+	if !strings.Contains(xml, "startArrow=ERzeroToMany;startFill=0;endArrow=ERmandOne;") {
 		t.Error("Should contain many-to-one relationship style")
 	}
 	
-	if !strings.Contains(xml, "startArrow=ERmany;endArrow=ERmany") {
+	if !strings.Contains(xml, "startArrow=ERzeroToMany;startFill=0;endArrow=ERzeroToMany;") {
 		t.Error("Should contain many-to-many relationship style")
 	}
 }
 
+func TestERDiagramCardinalityMarkers(t *testing.T) {
+	diagram := &mermaid.ERDiagram{
+		Entities: []mermaid.Entity{
+			{Name: "A"},
+			{Name: "B"},
+		},
+		Relationships: []mermaid.Relationship{
+			{From: "A", To: "B", FromEnd: mermaid.ZeroOrOne, ToEnd: mermaid.OneOrMore, Identification: mermaid.Identifying},
+			{From: "B", To: "A", FromEnd: mermaid.ExactlyOne, ToEnd: mermaid.ZeroOrMore, Identification: mermaid.NonIdentifying},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(xml, "startArrow=ERzeroToOne;startFill=0;endArrow=ERoneToMany;endFill=0;\"") {
+		t.Error("Identifying relationship should be a solid line with zero-or-one and one-or-more markers")
+	}
+	if !strings.Contains(xml, "startArrow=ERmandOne;startFill=0;endArrow=ERzeroToMany;endFill=0;dashed=1;") {
+		t.Error("Non-identifying relationship should be dashed")
+	}
+}
+
+func TestERDiagramAttributeComments(t *testing.T) {
+	diagram := &mermaid.ERDiagram{
+		Entities: []mermaid.Entity{
+			{
+				Name: "TEST",
+				Attributes: []mermaid.Attribute{
+					{Name: "scores", Type: "list~int~", Comment: "latest <first>"},
+				},
+			},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(xml, "scores: list&amp;lt;int&amp;gt;") {
+		t.Error("Generic types should be shown with escaped angle brackets")
+	}
+	if !strings.Contains(xml, `&lt;i style=&#34;color:#808080;&#34;&gt;latest &amp;lt;first&amp;gt;&lt;/i&gt;`) {
+		t.Error("Attribute comments should follow the attribute in grey italics")
+	}
+}
+
+func TestERDiagramEntityAliases(t *testing.T) {
+	diagram, err := mermaid.ParseERDiagram("erDiagram\n    CUSTOMER[\"Customer Account\"]\n    CUSTOMER ||--o{ ORDER : places")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(xml, `value="Customer Account"`) {
+		t.Error("Entity should be labelled with its alias")
+	}
+	if !strings.Contains(xml, `value="ORDER"`) {
+		t.Error("Entity only referenced by a relationship should be drawn")
+	}
+	if !strings.Contains(xml, `value="places"`) {
+		t.Error("Relationship to an undeclared entity should be drawn")
+	}
+}
+
 func TestERDiagramAttributeConstraints(t *testing.T) {
 	diagram := &mermaid.ERDiagram{
 		Entities: []mermaid.Entity{
@@ -384,4 +462,458 @@ type mockDiagram struct{}
 
 func (md *mockDiagram) GetType() mermaid.DiagramType {
 	return mermaid.DiagramType(999) // Unknown type
-}
\ No newline at end of file
+}
+
+func TestSequenceDiagramNotes(t *testing.T) {
+	diagram := &mermaid.SequenceDiagram{
+		Participants: []mermaid.Participant{
+			{Name: "A", Alias: "A"},
+			{Name: "B", Alias: "B"},
+		},
+		Messages: []mermaid.Message{
+			{From: "A", To: "B", Text: "Hello", Type: mermaid.SolidArrow},
+		},
+		Notes: []mermaid.Note{
+			{Placement: mermaid.NoteRightOf, Participants: []string{"A"}, Text: "first"},
+			{Placement: mermaid.NoteOver, Participants: []string{"A", "B"}, Text: "spanning"},
+			{Placement: mermaid.NoteLeftOf, Participants: []string{"X"}, Text: "unknown"},
+		},
+		Events: []mermaid.SequenceEvent{
+			{Kind: mermaid.NoteEvent, Index: 0},
+			{Kind: mermaid.MessageEvent, Index: 0},
+			{Kind: mermaid.NoteEvent, Index: 1},
+			{Kind: mermaid.NoteEvent, Index: 2},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if count := strings.Count(xml, "shape=note;"); count != 2 {
+		t.Errorf("Expected 2 notes, got %d", count)
+	}
+
+	// Right of A starts just after A's lifeline in the first row
+	if !strings.Contains(xml, `<mxGeometry x="110" y="132" width="120" height="36" as="geometry">`) {
+		t.Error("Note right of A should be placed beside A in the first row")
+	}
+
+	// Over A and B spans both participant boxes in the third row
+	if !strings.Contains(xml, `<mxGeometry x="50" y="232" width="300" height="36" as="geometry">`) {
+		t.Error("Note over A,B should span both participants in the third row")
+	}
+}
+
+func TestSequenceDiagramActivations(t *testing.T) {
+	diagram := &mermaid.SequenceDiagram{
+		Participants: []mermaid.Participant{
+			{Name: "A", Alias: "A"},
+			{Name: "B", Alias: "B"},
+		},
+		Messages: []mermaid.Message{
+			{From: "A", To: "B", Text: "call", Type: mermaid.SolidArrow, Activate: true},
+			{From: "A", To: "B", Text: "nested", Type: mermaid.SolidArrow, Activate: true},
+			{From: "B", To: "A", Text: "reply", Type: mermaid.DashedArrow, Deactivate: true},
+		},
+		Activations: []mermaid.Activation{
+			{Participant: "B", Start: 0, End: 2, Level: 0},
+			{Participant: "B", Start: 1, End: 2, Level: 1},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if count := strings.Count(xml, "perimeter=orthogonalPerimeter;"); count != 2 {
+		t.Errorf("Expected 2 activation bars, got %d", count)
+	}
+
+	// The nested bar is shifted right by half a bar width
+	if !strings.Contains(xml, `<mxGeometry x="295" y="150" width="10" height="100" as="geometry">`) {
+		t.Error("Outer activation bar should span rows 0 to 2 on B's lifeline")
+	}
+	if !strings.Contains(xml, `<mxGeometry x="300" y="200" width="10" height="50" as="geometry">`) {
+		t.Error("Nested activation bar should be offset and span rows 1 to 2")
+	}
+
+	// Messages attach to the bars instead of B's participant box
+	if !strings.Contains(xml, `target="activation_7"`) || !strings.Contains(xml, `source="activation_7"`) {
+		t.Error("Messages should attach to the innermost activation bar")
+	}
+	if !strings.Contains(xml, "exitX=0;exitY=1;") {
+		t.Error("The reply should leave the bottom left edge of the nested bar")
+	}
+}
+
+func TestSequenceDiagramFragments(t *testing.T) {
+	diagram := &mermaid.SequenceDiagram{
+		Participants: []mermaid.Participant{
+			{Name: "A", Alias: "A"},
+			{Name: "B", Alias: "B"},
+			{Name: "C", Alias: "C"},
+		},
+		Messages: []mermaid.Message{
+			{From: "A", To: "B", Text: "Ping"},
+			{From: "B", To: "A", Text: "Pong"},
+			{From: "B", To: "A", Text: "Help"},
+		},
+		Blocks: []mermaid.Block{
+			{Kind: mermaid.LoopBlock, Parent: -1, Start: 0, End: 7, Sections: []mermaid.BlockSection{{Label: "Every minute", Start: 0}}},
+			{Kind: mermaid.AltBlock, Parent: 0, Start: 2, End: 6, Sections: []mermaid.BlockSection{{Label: "healthy", Start: 2}, {Label: "sick", Start: 4}}},
+		},
+		Events: []mermaid.SequenceEvent{
+			{Kind: mermaid.BlockStartEvent, Index: 0},
+			{Kind: mermaid.MessageEvent, Index: 0},
+			{Kind: mermaid.BlockStartEvent, Index: 1},
+			{Kind: mermaid.MessageEvent, Index: 1},
+			{Kind: mermaid.BlockSectionEvent, Index: 1},
+			{Kind: mermaid.MessageEvent, Index: 2},
+			{Kind: mermaid.BlockEndEvent, Index: 1},
+			{Kind: mermaid.BlockEndEvent, Index: 0},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`value="loop" style="shape=umlFrame;`,
+		`value="alt" style="shape=umlFrame;`,
+		`value="[Every minute]"`,
+		`value="[healthy]"`,
+		`value="[sick]"`,
+		"line;strokeWidth=1;dashed=1;",
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML should contain %q", want)
+		}
+	}
+
+	// The alt frame encloses A and B only; the loop adds a nesting margin
+	if !strings.Contains(xml, `<mxGeometry x="30" y="140" width="340" height="350" as="geometry">`) {
+		t.Error("Loop frame should enclose the nested alt frame")
+	}
+	if !strings.Contains(xml, `<mxGeometry x="40" y="240" width="320" height="200" as="geometry">`) {
+		t.Error("Alt frame should enclose exactly its messages")
+	}
+	if strings.Count(xml, "shape=umlFrame;") != 2 {
+		t.Error("Expected exactly two fragment frames")
+	}
+}
+
+func TestSequenceDiagramTimelineLayout(t *testing.T) {
+	diagram := &mermaid.SequenceDiagram{
+		Participants: []mermaid.Participant{
+			{Name: "A", Alias: "A"},
+			{Name: "B", Alias: "B"},
+		},
+		Messages: []mermaid.Message{
+			{From: "A", To: "B", Text: "first"},
+			{From: "B", To: "A", Text: "two<br>lines", Type: mermaid.DashedArrow},
+			{From: "A", To: "B", Text: "third"},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Each message gets its own row; the two-line label makes its row taller
+	for _, want := range []string{
+		`<mxPoint x="100" y="150" as="sourcePoint"></mxPoint>`,
+		`<mxPoint x="300" y="150" as="targetPoint"></mxPoint>`,
+		`<mxPoint x="300" y="216" as="sourcePoint"></mxPoint>`,
+		`<mxPoint x="100" y="216" as="targetPoint"></mxPoint>`,
+		`<mxPoint x="100" y="266" as="sourcePoint"></mxPoint>`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML should contain %q", want)
+		}
+	}
+
+	// Lifelines run from the bottom of the participant box past the last row
+	if !strings.Contains(xml, `source="participant_2"`) {
+		t.Error("Lifeline should start at its participant box")
+	}
+	if strings.Count(xml, `<mxPoint x="300" y="316" as="targetPoint"></mxPoint>`) != 1 {
+		t.Error("B's lifeline should end below the last message")
+	}
+	if strings.Count(xml, `y="100" as="sourcePoint"`) != 2 {
+		t.Error("Both lifelines should start below the participant boxes")
+	}
+
+	// Free message ends are not attached to the participant boxes
+	if strings.Contains(xml, `target="participant_`) {
+		t.Error("Messages should not connect to participant boxes")
+	}
+}
+
+func TestSequenceDiagramSelfMessages(t *testing.T) {
+	diagram := &mermaid.SequenceDiagram{
+		Participants: []mermaid.Participant{
+			{Name: "A", Alias: "A"},
+			{Name: "B", Alias: "B"},
+		},
+		Messages: []mermaid.Message{
+			{From: "A", To: "A", Text: "think"},
+			{From: "B", To: "A", Text: "call", Type: mermaid.SolidArrow, Activate: true},
+			{From: "A", To: "A", Text: "recurse", Type: mermaid.SolidArrow, Activate: true},
+			{From: "A", To: "B", Text: "done"},
+		},
+		Activations: []mermaid.Activation{
+			{Participant: "A", Start: 1, End: 3, Level: 0},
+			{Participant: "A", Start: 2, End: 3, Level: 1},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A plain self-message loops out right of the lifeline and back below
+	for _, want := range []string{
+		`<mxPoint x="100" y="150" as="sourcePoint"></mxPoint>`,
+		`<mxPoint x="100" y="180" as="targetPoint"></mxPoint>`,
+		`<Array as="points">`,
+		`<mxPoint x="140" y="150"></mxPoint>`,
+		`<mxPoint x="140" y="180"></mxPoint>`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML should contain %q", want)
+		}
+	}
+
+	// The self-message row is taller, so the next message starts lower
+	if !strings.Contains(xml, `<mxPoint x="300" y="230" as="sourcePoint"></mxPoint>`) {
+		t.Error("The message after a self-message should leave room for the loop")
+	}
+
+	// The nested bar starts where the recursive call returns, and the call
+	// leaves the outer bar
+	if !strings.Contains(xml, `<mxGeometry x="100" y="310" width="10" height="50" as="geometry">`) {
+		t.Error("Nested bar should start at the return point of the self-message")
+	}
+	if !strings.Contains(xml, `source="activation_6" target="activation_7"`) {
+		t.Error("Recursive self-message should go from the outer bar to the nested bar")
+	}
+}
+
+func TestSequenceParticipantShapes(t *testing.T) {
+	diagram := &mermaid.SequenceDiagram{
+		Participants: []mermaid.Participant{
+			{Name: "A", Alias: "Actor", Kind: mermaid.ActorParticipant},
+			{Name: "B", Alias: "Boundary", Kind: mermaid.BoundaryParticipant},
+			{Name: "C", Alias: "Control", Kind: mermaid.ControlParticipant},
+			{Name: "E", Alias: "Entity", Kind: mermaid.EntityParticipant},
+			{Name: "D", Alias: "Database", Kind: mermaid.DatabaseParticipant},
+			{Name: "Q", Alias: "Queue", Kind: mermaid.QueueParticipant},
+		},
+		Messages: []mermaid.Message{
+			{From: "A", To: "D", Text: "query", Type: mermaid.SolidArrow},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"shape=umlActor;",
+		"shape=umlBoundary;",
+		"shape=umlControl;",
+		"shape=umlEntity;",
+		"shape=cylinder3;",
+		"direction=south;",
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML should contain %q", want)
+		}
+	}
+
+	// The actor figure is centred in its column and its lifeline starts
+	// below the name
+	if !strings.Contains(xml, `<mxGeometry x="92.5" y="50" width="15" height="30" as="geometry">`) {
+		t.Error("Actor should be centred at the top of its column")
+	}
+	if !strings.Contains(xml, "exitX=0.5;exitY=1;exitDx=0;exitDy=20;") {
+		t.Error("Actor lifeline should start below the name")
+	}
+	if strings.Count(xml, "lifeline_") != 6 {
+		t.Error("Every participant should have a lifeline")
+	}
+}
+
+func TestSequenceAutonumberBadges(t *testing.T) {
+	diagram := &mermaid.SequenceDiagram{
+		Participants: []mermaid.Participant{
+			{Name: "A", Alias: "A"},
+			{Name: "B", Alias: "B"},
+		},
+		Messages: []mermaid.Message{
+			{From: "A", To: "B", Text: "Hello", Type: mermaid.SolidArrow, Numbered: true, Number: 1},
+			{From: "B", To: "B", Text: "Think", Type: mermaid.SolidArrow, Numbered: true, Number: 2},
+			{From: "B", To: "A", Text: "Hi", Type: mermaid.DashedArrow},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if count := strings.Count(xml, `id="number_`); count != 2 {
+		t.Errorf("Expected 2 number badges, got %d", count)
+	}
+
+	// Labels keep their text; the badge sits on the start of the arrow
+	if !strings.Contains(xml, `value="Hello"`) {
+		t.Error("Message label should not be modified")
+	}
+	if !strings.Contains(xml, `value="1" style="ellipse;`) {
+		t.Error("First message should have badge 1")
+	}
+	if !strings.Contains(xml, `<mxGeometry x="91" y="141" width="18" height="18" as="geometry">`) {
+		t.Error("Badge should be centred on the start of the message")
+	}
+	if !strings.Contains(xml, `<mxGeometry x="291" y="191" width="18" height="18" as="geometry">`) {
+		t.Error("Self-message badge should be centred on the start of the loop")
+	}
+}
+
+func TestSequenceParticipantBoxes(t *testing.T) {
+	diagram := &mermaid.SequenceDiagram{
+		Participants: []mermaid.Participant{
+			{Name: "A", Alias: "A"},
+			{Name: "B", Alias: "B"},
+			{Name: "C", Alias: "C"},
+		},
+		Messages: []mermaid.Message{
+			{From: "A", To: "C", Text: "Hello", Type: mermaid.SolidArrow},
+		},
+		Boxes: []mermaid.Box{
+			{Title: "Backend <api>", Color: mermaid.Color{Hex: "#C896FF", Opacity: 50}, Participants: []string{"B", "C"}},
+			{Title: "Empty", Participants: []string{"X"}},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if strings.Count(xml, `id="box_`) != 1 {
+		t.Error("Expected only the box with known participants")
+	}
+	if !strings.Contains(xml, `id="box_2" value="Backend &amp;lt;api&amp;gt;"`) {
+		t.Error("Box should be emitted first so it stays behind the participants")
+	}
+	if !strings.Contains(xml, "fillColor=#C896FF;fillOpacity=50;") {
+		t.Error("Box should use its declared fill color and opacity")
+	}
+
+	// Spans columns B and C from the title band to below the lifelines
+	if !strings.Contains(xml, `<mxGeometry x="230" y="20" width="340" height="200" as="geometry">`) {
+		t.Error("Box should enclose B and C down to the end of their lifelines")
+	}
+
+	// B and C move with the box, so they are its children and are placed
+	// relative to it
+	if !strings.Contains(xml, "container=1;collapsible=0;") {
+		t.Error("Box should be a container")
+	}
+	if !strings.Contains(xml, `id="participant_3" value="A" style="rounded=0;whiteSpace=wrap;html=1;" vertex="1" parent="1"`) {
+		t.Error("A is outside the box and should stay on the page")
+	}
+	if !strings.Contains(xml, `id="participant_5" value="B" style="rounded=0;whiteSpace=wrap;html=1;" vertex="1" parent="box_2"`) ||
+		!strings.Contains(xml, `<mxGeometry x="20" y="30" width="100" height="50" as="geometry">`) {
+		t.Error("B should be placed inside the box")
+	}
+	if !strings.Contains(xml, `edge="1" parent="box_2" source="participant_7"`) || !strings.Contains(xml, `<mxPoint x="270" y="180" as="targetPoint">`) {
+		t.Error("C's lifeline should be placed inside the box")
+	}
+}
+
+func TestSequenceCreateAndDestroy(t *testing.T) {
+	diagram := &mermaid.SequenceDiagram{
+		Participants: []mermaid.Participant{
+			{Name: "A", Alias: "A"},
+			{Name: "B", Alias: "B", Created: true, CreatedAt: 1, Destroyed: true, DestroyedAt: 2},
+		},
+		Messages: []mermaid.Message{
+			{From: "A", To: "A", Text: "think", Type: mermaid.SolidArrow},
+			{From: "A", To: "B", Text: "create", Type: mermaid.SolidArrow},
+			{From: "A", To: "B", Text: "destroy", Type: mermaid.SolidCross},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Rows sit at y=150, 230 (after the self-message) and 280
+	if !strings.Contains(xml, `<mxGeometry x="250" y="205" width="100" height="50" as="geometry">`) {
+		t.Error("Created participant should be centred on its creating message")
+	}
+	if !strings.Contains(xml, `<mxPoint x="300" y="255" as="sourcePoint"></mxPoint>`) || !strings.Contains(xml, `<mxPoint x="300" y="280" as="targetPoint"></mxPoint>`) {
+		t.Error("Lifeline should run from the created participant to its destruction")
+	}
+	if !strings.Contains(xml, "shape=umlDestroy;") || !strings.Contains(xml, `<mxGeometry x="290" y="270" width="20" height="20" as="geometry">`) {
+		t.Error("Destroyed lifeline should end with a destruction marker")
+	}
+	if !strings.Contains(xml, `<mxPoint x="250" y="230" as="targetPoint"></mxPoint>`) || !strings.Contains(xml, "entryX=0;entryY=0.5;") {
+		t.Error("Creating message should end at the side of the new participant")
+	}
+}
+
+func TestSequenceRectHighlights(t *testing.T) {
+	diagram := &mermaid.SequenceDiagram{
+		Participants: []mermaid.Participant{
+			{Name: "A", Alias: "A"},
+			{Name: "B", Alias: "B"},
+			{Name: "C", Alias: "C"},
+		},
+		Messages: []mermaid.Message{
+			{From: "A", To: "B", Text: "hi", Type: mermaid.SolidArrow},
+			{From: "C", To: "A", Text: "done", Type: mermaid.SolidArrow},
+		},
+		Events: []mermaid.SequenceEvent{
+			{Kind: mermaid.BlockStartEvent, Index: 0},
+			{Kind: mermaid.MessageEvent, Index: 0},
+			{Kind: mermaid.BlockEndEvent, Index: 0},
+			{Kind: mermaid.MessageEvent, Index: 1},
+		},
+		Blocks: []mermaid.Block{
+			{Kind: mermaid.RectBlock, Sections: []mermaid.BlockSection{{Start: 0}}, Parent: -1, Start: 0, End: 2, Color: mermaid.Color{Hex: "#C896FF", Opacity: 50}},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(xml, `id="rect_2"`) {
+		t.Error("Rect should be emitted before the participants so it stays behind them")
+	}
+	if !strings.Contains(xml, "strokeColor=none;fillColor=#C896FF;fillOpacity=50;") {
+		t.Error("Rect should use its fill color and opacity")
+	}
+	if strings.Contains(xml, "shape=umlFrame") {
+		t.Error("Rect should not be drawn as a fragment frame")
+	}
+
+	// Spans the lifelines of A and B over rows 0 to 2
+	if !strings.Contains(xml, `<mxGeometry x="40" y="140" width="320" height="100" as="geometry">`) {
+		t.Error("Rect should cover the rows and lifelines of its messages")
+	}
+}
@@ -0,0 +1,380 @@
+package drawio
+
+import "sort"
+
+// layoutNode is a box placed by layeredLayout.
+type layoutNode struct {
+	ID     string
+	Width  float64
+	Height float64
+}
+
+type layoutEdge struct {
+	From string
+	To   string
+}
+
+type layoutPosition struct {
+	X float64
+	Y float64
+}
+
+// layeredLayout arranges nodes in ranks along the edge direction, similar to
+// the dagre layout Mermaid uses. Positions are top-left corners relative to
+// the origin; the returned width and height cover every node.
+func layeredLayout(nodes []layoutNode, edges []layoutEdge, direction string, rankGap, nodeGap float64) (map[string]layoutPosition, float64, float64) {
+	positions := make(map[string]layoutPosition)
+	if len(nodes) == 0 {
+		return positions, 0, 0
+	}
+
+	ranks := assignRanks(nodes, edges)
+	layers := orderLayers(nodes, edges, ranks)
+	horizontal := direction == "LR" || direction == "RL"
+
+	sizes := make(map[string]layoutNode)
+	for _, node := range nodes {
+		sizes[node.ID] = node
+	}
+	mainSize := func(id string) float64 {
+		if horizontal {
+			return sizes[id].Width
+		}
+		return sizes[id].Height
+	}
+	crossSize := func(id string) float64 {
+		if horizontal {
+			return sizes[id].Height
+		}
+		return sizes[id].Width
+	}
+
+	rankThickness := make([]float64, len(layers))
+	rankLength := make([]float64, len(layers))
+	maxLength := 0.0
+	for r, layer := range layers {
+		for i, id := range layer {
+			if mainSize(id) > rankThickness[r] {
+				rankThickness[r] = mainSize(id)
+			}
+			if i > 0 {
+				rankLength[r] += nodeGap
+			}
+			rankLength[r] += crossSize(id)
+		}
+		if rankLength[r] > maxLength {
+			maxLength = rankLength[r]
+		}
+	}
+
+	totalMain := 0.0
+	for r := range layers {
+		if r > 0 {
+			totalMain += rankGap
+		}
+		totalMain += rankThickness[r]
+	}
+
+	mainOffset := 0.0
+	for r, layer := range layers {
+		cross := (maxLength - rankLength[r]) / 2
+		for _, id := range layer {
+			main := mainOffset + (rankThickness[r]-mainSize(id))/2
+			if direction == "BT" || direction == "RL" {
+				main = totalMain - main - mainSize(id)
+			}
+			if horizontal {
+				positions[id] = layoutPosition{X: main, Y: cross}
+			} else {
+				positions[id] = layoutPosition{X: cross, Y: main}
+			}
+			cross += crossSize(id) + nodeGap
+		}
+		mainOffset += rankThickness[r] + rankGap
+	}
+
+	if horizontal {
+		return positions, totalMain, maxLength
+	}
+	return positions, maxLength, totalMain
+}
+
+// assignRanks gives every node the length of the longest path reaching it.
+// Edges closing a cycle are ignored so that loops still produce a layout.
+func assignRanks(nodes []layoutNode, edges []layoutEdge) map[string]int {
+	known := make(map[string]bool)
+	for _, node := range nodes {
+		known[node.ID] = true
+	}
+
+	adjacency := make(map[string][]string)
+	for _, edge := range edges {
+		if known[edge.From] && known[edge.To] && edge.From != edge.To {
+			adjacency[edge.From] = append(adjacency[edge.From], edge.To)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	forward := make(map[string][]string)
+	postOrder := make([]string, 0, len(nodes))
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		for _, to := range adjacency[id] {
+			switch state[to] {
+			case unvisited:
+				forward[id] = append(forward[id], to)
+				visit(to)
+			case visited:
+				forward[id] = append(forward[id], to)
+			}
+		}
+		state[id] = visited
+		postOrder = append(postOrder, id)
+	}
+	for _, node := range nodes {
+		if state[node.ID] == unvisited {
+			visit(node.ID)
+		}
+	}
+
+	ranks := make(map[string]int)
+	for i := len(postOrder) - 1; i >= 0; i-- {
+		id := postOrder[i]
+		for _, to := range forward[id] {
+			if ranks[id]+1 > ranks[to] {
+				ranks[to] = ranks[id] + 1
+			}
+		}
+	}
+	return ranks
+}
+
+// orderLayers groups nodes by rank and orders each rank by the average
+// position of its neighbours in the previous rank, which removes most edge
+// crossings for tree-like diagrams.
+func orderLayers(nodes []layoutNode, edges []layoutEdge, ranks map[string]int) [][]string {
+	maxRank := 0
+	for _, rank := range ranks {
+		if rank > maxRank {
+			maxRank = rank
+		}
+	}
+
+	layers := make([][]string, maxRank+1)
+	for _, node := range nodes {
+		rank := ranks[node.ID]
+		layers[rank] = append(layers[rank], node.ID)
+	}
+
+	predecessors := make(map[string][]string)
+	for _, edge := range edges {
+		if ranks[edge.From] < ranks[edge.To] {
+			predecessors[edge.To] = append(predecessors[edge.To], edge.From)
+		} else if ranks[edge.From] > ranks[edge.To] {
+			predecessors[edge.From] = append(predecessors[edge.From], edge.To)
+		}
+	}
+
+	order := make(map[string]float64)
+	for i, id := range layers[0] {
+		order[id] = float64(i)
+	}
+	for r := 1; r < len(layers); r++ {
+		layer := layers[r]
+		keys := make(map[string]float64)
+		for i, id := range layer {
+			keys[id] = float64(i)
+			sum, count := 0.0, 0
+			for _, pred := range predecessors[id] {
+				if position, ok := order[pred]; ok {
+					sum += position
+					count++
+				}
+			}
+			if count > 0 {
+				keys[id] = sum / float64(count)
+			}
+		}
+		sort.SliceStable(layer, func(a, b int) bool {
+			return keys[layer[a]] < keys[layer[b]]
+		})
+		for i, id := range layer {
+			order[id] = float64(i)
+		}
+	}
+
+	return layers
+}
+
+// nestedLayout runs layeredLayout once per container and then treats each
+// container as a single box inside its parent. Positions are relative to the
+// parent container, as draw.io expects for child cells; top-level items are
+// offset to the page origin.
+type nestedLayout struct {
+	direction string
+	rankGap   float64
+	nodeGap   float64
+	padding   float64
+	header    float64
+
+	order      []string
+	parents    map[string]string
+	containers map[string]nestedContainer
+	leaves     map[string]layoutNode
+	edges      []layoutEdge
+
+	children  map[string][]string
+	sizes     map[string]layoutNode
+	positions map[string]layoutPosition
+}
+
+type nestedContainer struct {
+	direction string
+	minWidth  float64
+	header    float64
+	left      float64 // room kept free beside the container in its parent
+	right     float64
+}
+
+func newNestedLayout(direction string, rankGap, nodeGap, padding, header float64) *nestedLayout {
+	return &nestedLayout{
+		direction:  direction,
+		rankGap:    rankGap,
+		nodeGap:    nodeGap,
+		padding:    padding,
+		header:     header,
+		parents:    make(map[string]string),
+		containers: make(map[string]nestedContainer),
+		leaves:     make(map[string]layoutNode),
+		children:   make(map[string][]string),
+		sizes:      make(map[string]layoutNode),
+		positions:  make(map[string]layoutPosition),
+	}
+}
+
+// addContainer registers a container. An empty direction inherits the
+// layout direction; minWidth keeps room for the container title.
+func (l *nestedLayout) addContainer(id, parent, direction string, minWidth float64) {
+	l.order = append(l.order, id)
+	l.parents[id] = parent
+	l.containers[id] = nestedContainer{direction: direction, minWidth: minWidth, header: l.header}
+}
+
+// setHeader overrides the title band height of a container, e.g. for
+// untitled regions.
+func (l *nestedLayout) setHeader(id string, header float64) {
+	container := l.containers[id]
+	container.header = header
+	l.containers[id] = container
+}
+
+// setMargins keeps room free to the left and right of a container, e.g. for
+// notes drawn beside it. The margins do not count towards its own size.
+func (l *nestedLayout) setMargins(id string, left, right float64) {
+	container := l.containers[id]
+	container.left, container.right = left, right
+	l.containers[id] = container
+}
+
+func (l *nestedLayout) addNode(id, parent string, width, height float64) {
+	l.order = append(l.order, id)
+	l.parents[id] = parent
+	l.leaves[id] = layoutNode{ID: id, Width: width, Height: height}
+}
+
+func (l *nestedLayout) addEdge(from, to string) {
+	l.edges = append(l.edges, layoutEdge{From: from, To: to})
+}
+
+func (l *nestedLayout) isContainer(id string) bool {
+	_, ok := l.containers[id]
+	return ok
+}
+
+// run computes sizes and positions. Items whose parent is not a registered
+// container are placed at the top level.
+func (l *nestedLayout) run() {
+	for _, id := range l.order {
+		parent := l.parents[id]
+		if !l.isContainer(parent) {
+			parent = ""
+			l.parents[id] = ""
+		}
+		l.children[parent] = append(l.children[parent], id)
+	}
+	l.measure("")
+}
+
+func (l *nestedLayout) measure(container string) (float64, float64) {
+	items := make([]layoutNode, 0, len(l.children[container]))
+	for _, child := range l.children[container] {
+		if !l.isContainer(child) {
+			l.sizes[child] = l.leaves[child]
+			items = append(items, l.sizes[child])
+			continue
+		}
+		width, height := l.measure(child)
+		l.sizes[child] = layoutNode{ID: child, Width: width, Height: height}
+		margins := l.containers[child].left + l.containers[child].right
+		items = append(items, layoutNode{ID: child, Width: width + margins, Height: height})
+	}
+
+	direction := l.direction
+	offsetX, offsetY := StartX, StartY
+	if l.isContainer(container) {
+		if l.containers[container].direction != "" {
+			direction = l.containers[container].direction
+		}
+		offsetX, offsetY = l.padding, l.containers[container].header+l.padding
+	}
+
+	positions, width, height := layeredLayout(items, l.edgesWithin(container), direction, l.rankGap, l.nodeGap)
+	for id, position := range positions {
+		l.positions[id] = layoutPosition{X: offsetX + position.X + l.containers[id].left, Y: offsetY + position.Y}
+	}
+
+	width += 2 * l.padding
+	if minWidth := l.containers[container].minWidth; minWidth > width {
+		width = minWidth
+	}
+	return width, height + l.containers[container].header + 2*l.padding
+}
+
+// edgesWithin projects every edge onto the direct children of container, so
+// an edge into a nested item still ranks the container holding it.
+func (l *nestedLayout) edgesWithin(container string) []layoutEdge {
+	var edges []layoutEdge
+	for _, edge := range l.edges {
+		from := l.childContaining(container, edge.From)
+		to := l.childContaining(container, edge.To)
+		if from != "" && to != "" && from != to {
+			edges = append(edges, layoutEdge{From: from, To: to})
+		}
+	}
+	return edges
+}
+
+// childContaining returns the direct child of container that is id or holds
+// id, or "" when id lies outside container.
+func (l *nestedLayout) childContaining(container, id string) string {
+	for {
+		parent, known := l.parents[id]
+		if !known {
+			return ""
+		}
+		if parent == container {
+			return id
+		}
+		if parent == "" {
+			return ""
+		}
+		id = parent
+	}
+}
@@ -0,0 +1,260 @@
+package drawio
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"unicode/utf8"
+
+	"mermaid2drawio/internal/mermaid"
+)
+
+// Layout constants for mind maps
+const (
+	MindmapNodeHeight = 40.0
+	MindmapMinWidth   = 80.0
+	MindmapCharWidth  = 8.0
+	MindmapPadding    = 16.0
+	MindmapLevelGap   = 60.0 // horizontal gap between a node and its children
+	MindmapSiblingGap = 16.0
+	MindmapLineHeight = 16.0
+	MindmapRootScale  = 1.4 // the root is drawn larger than its branches
+)
+
+var mindmapBreakRegex = regexp.MustCompile(`<br\s*/?>`)
+
+// mindmapBranchColors holds fill and stroke pairs; every first-level branch
+// takes the next pair so that a branch keeps one color throughout.
+var mindmapBranchColors = [][2]string{
+	{"#dae8fc", "#6c8ebf"},
+	{"#d5e8d4", "#82b366"},
+	{"#ffe6cc", "#d79b00"},
+	{"#e1d5e7", "#9673a6"},
+	{"#fff2cc", "#d6b656"},
+	{"#f8cecc", "#b85450"},
+}
+
+// mindmapLayout places the root in the middle with its branches balanced
+// between the right and the left side, each side growing outwards.
+type mindmapLayout struct {
+	children  [][]int
+	sizes     []layoutNode
+	heights   []float64 // height of each subtree including gaps
+	positions []layoutPosition
+}
+
+func GenerateMindmapDrawIOXML(diagram *mermaid.MindmapDiagram) (string, error) {
+	model := createBaseModel()
+
+	cells := createDefaultCells()
+	cellID := 2
+
+	if len(diagram.Nodes) == 0 {
+		model.Root.MxCells = cells
+		return generateXMLOutput(model)
+	}
+
+	layout := newMindmapLayout(diagram)
+	layout.run()
+
+	branches := make([]int, len(diagram.Nodes))
+	nodeCells := make([]string, len(diagram.Nodes))
+	branchCount := 0
+	for i, node := range diagram.Nodes {
+		switch {
+		case node.Parent < 0:
+			branches[i] = -1
+		case node.Level == 1:
+			branches[i] = branchCount
+			branchCount++
+		default:
+			branches[i] = branches[node.Parent]
+		}
+
+		x, y := layout.positions[i].X, layout.positions[i].Y
+		width, height := layout.sizes[i].Width, layout.sizes[i].Height
+		nodeCells[i] = fmt.Sprintf("node_%d", cellID)
+		cells = append(cells, MxCell{
+			ID:     nodeCells[i],
+			Value:  mindmapValue(node),
+			Style:  mindmapNodeStyle(node.Shape) + mindmapColorStyle(branches[i]),
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &y,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
+	}
+
+	// Curved connectors follow the nodes when the map is rearranged
+	for i, node := range diagram.Nodes {
+		if node.Parent < 0 {
+			continue
+		}
+		stroke := mindmapBranchColors[branches[i]%len(mindmapBranchColors)][1]
+		cells = append(cells, MxCell{
+			ID:     fmt.Sprintf("edge_%d", cellID),
+			Style:  fmt.Sprintf("edgeStyle=entityRelationEdgeStyle;curved=1;html=1;startArrow=none;endArrow=none;strokeWidth=2;strokeColor=%s;", stroke),
+			Edge:   "1",
+			Parent: "1",
+			Source: nodeCells[node.Parent],
+			Target: nodeCells[i],
+			Geometry: &MxGeometry{
+				Relative: "1",
+				As:       "geometry",
+			},
+		})
+		cellID++
+	}
+
+	model.Root.MxCells = cells
+	return generateXMLOutput(model)
+}
+
+func newMindmapLayout(diagram *mermaid.MindmapDiagram) *mindmapLayout {
+	layout := &mindmapLayout{
+		children:  make([][]int, len(diagram.Nodes)),
+		sizes:     make([]layoutNode, len(diagram.Nodes)),
+		heights:   make([]float64, len(diagram.Nodes)),
+		positions: make([]layoutPosition, len(diagram.Nodes)),
+	}
+	for i, node := range diagram.Nodes {
+		if node.Parent >= 0 {
+			layout.children[node.Parent] = append(layout.children[node.Parent], i)
+		}
+		width, height := mindmapNodeSize(node)
+		layout.sizes[i] = layoutNode{Width: width, Height: height}
+	}
+	// Children always follow their parent in source order
+	for i := len(diagram.Nodes) - 1; i >= 0; i-- {
+		layout.heights[i] = max(layout.sizes[i].Height, layout.blockHeight(layout.children[i]))
+	}
+	return layout
+}
+
+func (l *mindmapLayout) blockHeight(nodes []int) float64 {
+	height := 0.0
+	for i, node := range nodes {
+		if i > 0 {
+			height += MindmapSiblingGap
+		}
+		height += l.heights[node]
+	}
+	return height
+}
+
+func (l *mindmapLayout) run() {
+	// Each branch goes to the side that is currently shorter
+	var right, left []int
+	for _, child := range l.children[0] {
+		if l.blockHeight(left) < l.blockHeight(right) {
+			left = append(left, child)
+		} else {
+			right = append(right, child)
+		}
+	}
+
+	root := l.sizes[0]
+	l.positions[0] = layoutPosition{X: -root.Width / 2, Y: -root.Height / 2}
+	l.placeBlock(right, 1, root.Width/2, 0)
+	l.placeBlock(left, -1, -root.Width/2, 0)
+
+	// Shift everything so that the top-left corner sits at the origin
+	minX, minY := math.Inf(1), math.Inf(1)
+	for _, position := range l.positions {
+		minX = min(minX, position.X)
+		minY = min(minY, position.Y)
+	}
+	for i := range l.positions {
+		l.positions[i].X -= minX
+		l.positions[i].Y -= minY
+	}
+}
+
+// placeBlock stacks subtrees vertically around centerY, starting
+// MindmapLevelGap away from edgeX in the given direction.
+func (l *mindmapLayout) placeBlock(nodes []int, direction, edgeX, centerY float64) {
+	top := centerY - l.blockHeight(nodes)/2
+	for _, node := range nodes {
+		size := l.sizes[node]
+		nodeCenter := top + l.heights[node]/2
+		x := edgeX + MindmapLevelGap
+		childEdge := x + size.Width
+		if direction < 0 {
+			x = edgeX - MindmapLevelGap - size.Width
+			childEdge = x
+		}
+		l.positions[node] = layoutPosition{X: x, Y: nodeCenter - size.Height/2}
+		l.placeBlock(l.children[node], direction, childEdge, nodeCenter)
+		top += l.heights[node] + MindmapSiblingGap
+	}
+}
+
+// mindmapNodeSize fits the longest label line; <br> starts a new line.
+func mindmapNodeSize(node mermaid.MindmapNode) (float64, float64) {
+	lines := mindmapBreakRegex.Split(node.Label, -1)
+	longest := 0
+	for _, line := range lines {
+		longest = max(longest, utf8.RuneCountInString(line))
+	}
+	width := max(MindmapMinWidth, float64(longest)*MindmapCharWidth+2*MindmapPadding)
+	height := MindmapNodeHeight + float64(len(lines)-1)*MindmapLineHeight
+	if node.Icon != "" {
+		height += MindmapLineHeight
+	}
+	if node.Parent < 0 {
+		width, height = math.Round(width*MindmapRootScale), math.Round(height*MindmapRootScale)
+	}
+
+	switch node.Shape {
+	case mermaid.MindmapCircle:
+		return width, width
+	case mermaid.MindmapBang, mermaid.MindmapCloud:
+		return math.Round(width * 1.3), math.Round(height * 1.6)
+	default:
+		return width, height
+	}
+}
+
+func mindmapNodeStyle(shape mermaid.MindmapShape) string {
+	switch shape {
+	case mermaid.MindmapSquare:
+		return "rounded=0;whiteSpace=wrap;html=1;"
+	case mermaid.MindmapRounded:
+		return "rounded=1;arcSize=40;whiteSpace=wrap;html=1;"
+	case mermaid.MindmapCircle:
+		return "ellipse;whiteSpace=wrap;html=1;aspect=fixed;"
+	case mermaid.MindmapBang:
+		return "shape=mxgraph.basic.star;whiteSpace=wrap;html=1;"
+	case mermaid.MindmapCloud:
+		return "ellipse;shape=cloud;whiteSpace=wrap;html=1;"
+	case mermaid.MindmapHexagon:
+		return "shape=hexagon;perimeter=hexagonPerimeter2;whiteSpace=wrap;html=1;fixedSize=1;"
+	default:
+		return "rounded=1;whiteSpace=wrap;html=1;"
+	}
+}
+
+// mindmapColorStyle colors a node by its branch; the root (-1) is dark.
+func mindmapColorStyle(branch int) string {
+	if branch < 0 {
+		return "fillColor=#647687;strokeColor=#314354;fontColor=#ffffff;fontStyle=1;"
+	}
+	colors := mindmapBranchColors[branch%len(mindmapBranchColors)]
+	return fmt.Sprintf("fillColor=%s;strokeColor=%s;", colors[0], colors[1])
+}
+
+// mindmapValue shows the icon name as a small caption above the label since
+// draw.io cannot render Mermaid's icon fonts.
+func mindmapValue(node mermaid.MindmapNode) string {
+	if node.Icon == "" {
+		return node.Label
+	}
+	return fmt.Sprintf(`<font style="font-size:9px;color:#808080;">%s</font><br>%s`, html.EscapeString(node.Icon), node.Label)
+}
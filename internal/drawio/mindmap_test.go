@@ -0,0 +1,73 @@
+package drawio
+
+import (
+	"mermaid2drawio/internal/mermaid"
+	"strings"
+	"testing"
+)
+
+func TestGenerateMindmapDrawIOXML(t *testing.T) {
+	diagram := &mermaid.MindmapDiagram{
+		Nodes: []mermaid.MindmapNode{
+			{ID: "Root", Label: "Root", Parent: -1},
+			{ID: "A", Label: "A", Parent: 0, Level: 1, Icon: "fa fa-book"},
+			{ID: "A1", Label: "A1", Shape: mermaid.MindmapCloud, Parent: 1, Level: 2},
+			{ID: "B", Label: "B", Shape: mermaid.MindmapHexagon, Parent: 0, Level: 1},
+			{ID: "C", Label: "C", Shape: mermaid.MindmapCircle, Parent: 0, Level: 1},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		// The root is enlarged and sits between the two sides
+		`<mxGeometry x="140" y="40" width="112" height="56" as="geometry">`,
+		"fillColor=#647687;strokeColor=#314354;fontColor=#ffffff;fontStyle=1;",
+		// A and its taller subtree go right, B and C balance it on the left
+		`<mxGeometry x="312" y="40" width="80" height="56" as="geometry">`,
+		`<mxGeometry x="452" y="36" width="104" height="64" as="geometry">`,
+		`<mxGeometry x="0" y="0" width="80" height="40" as="geometry">`,
+		`<mxGeometry x="0" y="56" width="80" height="80" as="geometry">`,
+		// Shapes and branch colors
+		"ellipse;shape=cloud;whiteSpace=wrap;html=1;fillColor=#dae8fc;strokeColor=#6c8ebf;",
+		"shape=hexagon;perimeter=hexagonPerimeter2;whiteSpace=wrap;html=1;fixedSize=1;fillColor=#d5e8d4;",
+		"ellipse;whiteSpace=wrap;html=1;aspect=fixed;fillColor=#ffe6cc;",
+		"fa fa-book",
+		// Curved connectors take the color of their branch
+		"edgeStyle=entityRelationEdgeStyle;curved=1;html=1;startArrow=none;endArrow=none;strokeWidth=2;strokeColor=#6c8ebf;",
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML should contain %q", want)
+		}
+	}
+
+	if count := strings.Count(xml, `id="edge_`); count != 4 {
+		t.Errorf("Expected 4 connectors, got %d", count)
+	}
+}
+
+func TestMindmapNodeSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		node   mermaid.MindmapNode
+		width  float64
+		height float64
+	}{
+		{"short label", mermaid.MindmapNode{Label: "A", Parent: 0}, 80, 40},
+		{"long label", mermaid.MindmapNode{Label: "Strategic planning", Parent: 0}, 176, 40},
+		{"line break", mermaid.MindmapNode{Label: "On effectiveness<br/>and features", Parent: 0}, 160, 56},
+		{"circle", mermaid.MindmapNode{Label: "Strategic planning", Shape: mermaid.MindmapCircle, Parent: 0}, 176, 176},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height := mindmapNodeSize(tt.node)
+			if width != tt.width || height != tt.height {
+				t.Errorf("Expected %vx%v, got %vx%v", tt.width, tt.height, width, height)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package drawio
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"mermaid2drawio/internal/mermaid"
+)
+
+// Layout constants for pie charts
+const (
+	PieRadius          = 150.0
+	PieTitleHeight     = 40.0
+	PieLabelWidth      = 60.0
+	PieLabelHeight     = 20.0
+	PieLabelRadius     = 0.65 // slice labels sit at this fraction of the radius
+	PieMinLabelPercent = 1.0  // smaller slices get no label on the pie
+	PieLegendGap       = 40.0
+	PieLegendSwatch    = 16.0
+	PieLegendRowHeight = 24.0
+	PieCharWidth       = 7.0
+)
+
+// piePalette is cycled through in slice order so that the same input always
+// produces the same colors.
+var piePalette = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948",
+	"#b07aa1", "#ff9da7", "#9c755f", "#bab0ac", "#86bcb6", "#d37295",
+}
+
+func GeneratePieDrawIOXML(diagram *mermaid.PieDiagram) (string, error) {
+	model := createBaseModel()
+
+	cells := createDefaultCells()
+	cellID := 2
+
+	total := 0.0
+	for _, slice := range diagram.Slices {
+		total += slice.Value
+	}
+
+	top := 0.0
+	if diagram.Title != "" {
+		x, y, width, height := 0.0, 0.0, 2*PieRadius+PieLegendGap+pieLegendWidth(diagram, total), PieTitleHeight
+		cells = append(cells, MxCell{
+			ID:     fmt.Sprintf("pie_%d", cellID),
+			Value:  html.EscapeString(diagram.Title),
+			Style:  "text;html=1;align=center;verticalAlign=middle;fontStyle=1;fontSize=16;",
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &y,
+				Width:  &width,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
+		top = PieTitleHeight
+	}
+
+	// Slices start at 12 o'clock and run clockwise; draw.io angles are
+	// fractions of a full turn
+	centerX, centerY := PieRadius, top+PieRadius
+	start := 0.0
+	for i, slice := range diagram.Slices {
+		if slice.Value == 0 || total == 0 {
+			continue
+		}
+		fraction := slice.Value / total
+		end := start + fraction
+
+		style := fmt.Sprintf("shape=mxgraph.basic.pie;startAngle=%s;endAngle=%s;", formatAngle(start), formatAngle(end))
+		if fraction >= 1 {
+			style = "ellipse;"
+		}
+		x, y, size := 0.0, top, 2*PieRadius
+		cells = append(cells, MxCell{
+			ID:     fmt.Sprintf("slice_%d", cellID),
+			Style:  style + fmt.Sprintf("whiteSpace=wrap;html=1;fillColor=%s;strokeColor=#ffffff;strokeWidth=2;", pieColor(i)),
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &x,
+				Y:      &y,
+				Width:  &size,
+				Height: &size,
+				As:     "geometry",
+			},
+		})
+		cellID++
+
+		if percent := fraction * 100; percent >= PieMinLabelPercent {
+			angle := (start + fraction/2) * 2 * math.Pi
+			labelX := centerX + PieRadius*PieLabelRadius*math.Sin(angle) - PieLabelWidth/2
+			labelY := centerY - PieRadius*PieLabelRadius*math.Cos(angle) - PieLabelHeight/2
+			if fraction >= 1 {
+				labelX, labelY = centerX-PieLabelWidth/2, centerY-PieLabelHeight/2
+			}
+			labelX, labelY = math.Round(labelX), math.Round(labelY)
+			width, height := PieLabelWidth, PieLabelHeight
+			cells = append(cells, MxCell{
+				ID:     fmt.Sprintf("label_%d", cellID),
+				Value:  formatPercent(percent),
+				Style:  "text;html=1;align=center;verticalAlign=middle;fontColor=#ffffff;fontStyle=1;",
+				Vertex: "1",
+				Parent: "1",
+				Geometry: &MxGeometry{
+					X:      &labelX,
+					Y:      &labelY,
+					Width:  &width,
+					Height: &height,
+					As:     "geometry",
+				},
+			})
+			cellID++
+		}
+		start = end
+	}
+
+	// Legend to the right of the pie, vertically centered on it
+	legendX := 2*PieRadius + PieLegendGap
+	legendY := centerY - float64(len(diagram.Slices))*PieLegendRowHeight/2
+	textWidth := pieLegendWidth(diagram, total) - PieLegendSwatch - 8
+	for i, slice := range diagram.Slices {
+		rowY := legendY + float64(i)*PieLegendRowHeight
+		swatchX, swatchY, swatchSize := legendX, rowY+(PieLegendRowHeight-PieLegendSwatch)/2, PieLegendSwatch
+		cells = append(cells, MxCell{
+			ID:     fmt.Sprintf("legend_%d", cellID),
+			Style:  fmt.Sprintf("rounded=0;whiteSpace=wrap;html=1;fillColor=%s;strokeColor=none;", pieColor(i)),
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &swatchX,
+				Y:      &swatchY,
+				Width:  &swatchSize,
+				Height: &swatchSize,
+				As:     "geometry",
+			},
+		})
+		cellID++
+
+		textX, textY, height := legendX+PieLegendSwatch+8, rowY, PieLegendRowHeight
+		cells = append(cells, MxCell{
+			ID:     fmt.Sprintf("legend_%d", cellID),
+			Value:  html.EscapeString(pieLegendText(diagram, slice, total)),
+			Style:  "text;html=1;align=left;verticalAlign=middle;",
+			Vertex: "1",
+			Parent: "1",
+			Geometry: &MxGeometry{
+				X:      &textX,
+				Y:      &textY,
+				Width:  &textWidth,
+				Height: &height,
+				As:     "geometry",
+			},
+		})
+		cellID++
+	}
+
+	model.Root.MxCells = cells
+	return generateXMLOutput(model)
+}
+
+func pieColor(index int) string {
+	return piePalette[index%len(piePalette)]
+}
+
+// pieLegendText renders "Label (25%)", or "Label [42] (25%)" with showData.
+func pieLegendText(diagram *mermaid.PieDiagram, slice mermaid.PieSlice, total float64) string {
+	percent := 0.0
+	if total > 0 {
+		percent = slice.Value / total * 100
+	}
+	text := slice.Label
+	if diagram.ShowData {
+		text += " [" + strconv.FormatFloat(slice.Value, 'f', -1, 64) + "]"
+	}
+	return text + " (" + formatPercent(percent) + ")"
+}
+
+// pieLegendWidth covers the swatch and the longest legend entry.
+func pieLegendWidth(diagram *mermaid.PieDiagram, total float64) float64 {
+	width := 0.0
+	for _, slice := range diagram.Slices {
+		width = max(width, float64(utf8.RuneCountInString(pieLegendText(diagram, slice, total)))*PieCharWidth)
+	}
+	return PieLegendSwatch + 8 + width
+}
+
+// formatPercent keeps at most one decimal, e.g. "46.2%" or "50%".
+func formatPercent(percent float64) string {
+	return strconv.FormatFloat(math.Round(percent*10)/10, 'f', -1, 64) + "%"
+}
+
+// formatAngle limits angles to four decimals so rounding noise does not
+// leak into the style.
+func formatAngle(angle float64) string {
+	return strconv.FormatFloat(math.Round(angle*10000)/10000, 'f', -1, 64)
+}
@@ -0,0 +1,84 @@
+package drawio
+
+import (
+	"mermaid2drawio/internal/mermaid"
+	"strings"
+	"testing"
+)
+
+func TestGeneratePieDrawIOXML(t *testing.T) {
+	diagram := &mermaid.PieDiagram{
+		Title:    "Pets & owners",
+		ShowData: true,
+		Slices: []mermaid.PieSlice{
+			{Label: "Dogs", Value: 50},
+			{Label: "Cats", Value: 25},
+			{Label: "Rats", Value: 25},
+			{Label: "Fish", Value: 0},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`value="Pets &amp;amp; owners"`,
+		// Slices run clockwise from 12 o'clock in source order
+		"shape=mxgraph.basic.pie;startAngle=0;endAngle=0.5;whiteSpace=wrap;html=1;fillColor=#4e79a7;",
+		"shape=mxgraph.basic.pie;startAngle=0.5;endAngle=0.75;whiteSpace=wrap;html=1;fillColor=#f28e2b;",
+		"shape=mxgraph.basic.pie;startAngle=0.75;endAngle=1;whiteSpace=wrap;html=1;fillColor=#e15759;",
+		`<mxGeometry x="0" y="40" width="300" height="300" as="geometry">`,
+		// Percentage label in the middle of the right half
+		`value="50%"`,
+		`<mxGeometry x="218" y="180" width="60" height="20" as="geometry">`,
+		// Legend lists every slice with its value and percentage
+		`value="Dogs [50] (50%)"`,
+		`value="Fish [0] (0%)"`,
+		"fillColor=#76b7b2;strokeColor=none;",
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML should contain %q", want)
+		}
+	}
+
+	// The empty slice is only listed in the legend
+	if count := strings.Count(xml, `id="slice_`); count != 3 {
+		t.Errorf("Expected 3 slices, got %d", count)
+	}
+}
+
+func TestGeneratePieDrawIOXMLSingleSlice(t *testing.T) {
+	diagram := &mermaid.PieDiagram{
+		Slices: []mermaid.PieSlice{{Label: "All", Value: 3}},
+	}
+
+	xml, err := GeneratePieDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(xml, "ellipse;whiteSpace=wrap;html=1;fillColor=#4e79a7;") {
+		t.Errorf("A single slice should be drawn as a full circle")
+	}
+	if !strings.Contains(xml, `value="All (100%)"`) {
+		t.Errorf("Legend should show the label and percentage")
+	}
+}
+
+func TestFormatPercent(t *testing.T) {
+	tests := []struct {
+		percent  float64
+		expected string
+	}{
+		{50, "50%"},
+		{46.2654, "46.3%"},
+		{0.04, "0%"},
+	}
+
+	for _, tt := range tests {
+		if got := formatPercent(tt.percent); got != tt.expected {
+			t.Errorf("formatPercent(%v) = %q, want %q", tt.percent, got, tt.expected)
+		}
+	}
+}
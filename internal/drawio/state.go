@@ -0,0 +1,327 @@
+package drawio
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"mermaid2drawio/internal/mermaid"
+)
+
+// Layout constants for state diagrams
+const (
+	StateWidth          = 120.0
+	StateHeight         = 40.0
+	StateCharWidth      = 8.0
+	PseudoStateSize     = 30.0
+	ChoiceStateSize     = 40.0
+	ForkBarLength       = 120.0
+	ForkBarThickness    = 10.0
+	StateRankSpacing    = 60.0
+	StateSpacing        = 40.0
+	StateNoteWidth      = 140.0
+	StateNoteLineHeight = 18.0
+	StateNoteGap        = 20.0
+	CompositePadding    = 20.0
+	CompositeHeader     = 30.0
+)
+
+func GenerateStateDrawIOXML(diagram *mermaid.StateDiagram) (string, error) {
+	model := createBaseModel()
+
+	cells := createDefaultCells()
+	cellID := 2
+	stateCells := make(map[string]string)
+
+	states := make(map[string]mermaid.State)
+	regions := make(map[string]int)
+	for _, state := range diagram.States {
+		states[state.ID] = state
+		if state.Parent != "" && state.Region+1 > regions[state.Parent] {
+			regions[state.Parent] = state.Region + 1
+		}
+	}
+
+	notes := make(map[string][]mermaid.StateNote)
+	for _, note := range diagram.Notes {
+		notes[note.State] = append(notes[note.State], note)
+	}
+
+	// States with notes get a wider layout box that holds the notes
+	layout := newNestedLayout(diagram.Direction, StateRankSpacing, StateSpacing, CompositePadding, CompositeHeader)
+	for _, state := range diagram.States {
+		parent := stateLayoutParent(state, regions)
+		if state.Kind == mermaid.CompositeState {
+			minWidth := float64(utf8.RuneCountInString(state.Label))*StateCharWidth + 2*CompositePadding
+			layout.addContainer(state.ID, parent, state.Direction, minWidth)
+			var left, right float64
+			for _, note := range notes[state.ID] {
+				noteWidth, _ := stateNoteSize(note)
+				if note.Position == "left" {
+					left += noteWidth + StateNoteGap
+				} else {
+					right += StateNoteGap + noteWidth
+				}
+			}
+			layout.setMargins(state.ID, left, right)
+			for region := 0; region < regions[state.ID] && regions[state.ID] > 1; region++ {
+				regionID := stateRegionID(state.ID, region)
+				layout.addContainer(regionID, state.ID, state.Direction, 0)
+				layout.setHeader(regionID, 0)
+			}
+			continue
+		}
+		width, height := stateSize(state, diagram.Direction)
+		boxWidth, boxHeight := width, height
+		for _, note := range notes[state.ID] {
+			noteWidth, noteHeight := stateNoteSize(note)
+			boxWidth += StateNoteGap + noteWidth
+			boxHeight = max(boxHeight, noteHeight)
+		}
+		layout.addNode(state.ID, parent, boxWidth, boxHeight)
+	}
+	for _, transition := range diagram.Transitions {
+		layout.addEdge(transition.From, transition.To)
+	}
+	layout.run()
+
+	// Create states, parents before children
+	var emit func(container, parentID string)
+	emit = func(container, parentID string) {
+		for _, child := range layout.children[container] {
+			x := layout.positions[child].X
+			y := layout.positions[child].Y
+			width := layout.sizes[child].Width
+			height := layout.sizes[child].Height
+
+			state, isState := states[child]
+			if layout.isContainer(child) {
+				cell := MxCell{
+					ID:     fmt.Sprintf("region_%d", cellID),
+					Style:  "rounded=0;whiteSpace=wrap;html=1;container=1;collapsible=0;dashed=1;fillColor=none;",
+					Vertex: "1",
+					Parent: parentID,
+					Geometry: &MxGeometry{
+						X:      &x,
+						Y:      &y,
+						Width:  &width,
+						Height: &height,
+						As:     "geometry",
+					},
+				}
+				if isState {
+					cell.ID = fmt.Sprintf("state_%d", cellID)
+					cell.Value = html.EscapeString(state.Label)
+					cell.Style = "swimlane;rounded=1;arcSize=10;fontStyle=1;whiteSpace=wrap;html=1;container=1;collapsible=0;startSize=30;"
+					stateCells[child] = cell.ID
+				}
+				cells = append(cells, cell)
+				cellID++
+				emit(child, cell.ID)
+
+				// Notes on a composite state sit in the margins kept beside it
+				leftX, rightX := x, x+width
+				for _, note := range notes[child] {
+					noteWidth, noteHeight := stateNoteSize(note)
+					noteX := rightX + StateNoteGap
+					if note.Position == "left" {
+						noteX = leftX - StateNoteGap - noteWidth
+						leftX = noteX
+					} else {
+						rightX = noteX + noteWidth
+					}
+					noteY := y
+
+					cells = append(cells, MxCell{
+						ID:     fmt.Sprintf("note_%d", cellID),
+						Value:  strings.ReplaceAll(html.EscapeString(note.Text), "\n", "<br>"),
+						Style:  "shape=note;whiteSpace=wrap;html=1;backgroundOutline=1;size=14;align=left;spacingLeft=6;",
+						Vertex: "1",
+						Parent: parentID,
+						Geometry: &MxGeometry{
+							X:      &noteX,
+							Y:      &noteY,
+							Width:  &noteWidth,
+							Height: &noteHeight,
+							As:     "geometry",
+						},
+					})
+					cellID++
+				}
+				continue
+			}
+
+			// Notes on the left push the state to the right of its box
+			stateWidth, stateHeight := stateSize(state, diagram.Direction)
+			stateX := x
+			for _, note := range notes[child] {
+				if note.Position == "left" {
+					noteWidth, _ := stateNoteSize(note)
+					stateX += noteWidth + StateNoteGap
+				}
+			}
+			stateY := y + (height-stateHeight)/2
+
+			stateID := fmt.Sprintf("state_%d", cellID)
+			stateCells[child] = stateID
+			cells = append(cells, MxCell{
+				ID:     stateID,
+				Value:  stateValue(state),
+				Style:  stateStyle(state.Kind),
+				Vertex: "1",
+				Parent: parentID,
+				Geometry: &MxGeometry{
+					X:      &stateX,
+					Y:      &stateY,
+					Width:  &stateWidth,
+					Height: &stateHeight,
+					As:     "geometry",
+				},
+			})
+			cellID++
+
+			leftX, rightX := stateX, stateX+stateWidth
+			for _, note := range notes[child] {
+				noteWidth, noteHeight := stateNoteSize(note)
+				noteX := rightX + StateNoteGap
+				if note.Position == "left" {
+					noteX = leftX - StateNoteGap - noteWidth
+					leftX = noteX
+				} else {
+					rightX = noteX + noteWidth
+				}
+				noteY := y + (height-noteHeight)/2
+
+				noteID := fmt.Sprintf("note_%d", cellID)
+				cells = append(cells, MxCell{
+					ID:     noteID,
+					Value:  strings.ReplaceAll(html.EscapeString(note.Text), "\n", "<br>"),
+					Style:  "shape=note;whiteSpace=wrap;html=1;backgroundOutline=1;size=14;align=left;spacingLeft=6;",
+					Vertex: "1",
+					Parent: parentID,
+					Geometry: &MxGeometry{
+						X:      &noteX,
+						Y:      &noteY,
+						Width:  &noteWidth,
+						Height: &noteHeight,
+						As:     "geometry",
+					},
+				})
+				cellID++
+
+				cells = append(cells, MxCell{
+					ID:     fmt.Sprintf("note_link_%d", cellID),
+					Style:  "endArrow=none;dashed=1;html=1;",
+					Edge:   "1",
+					Parent: parentID,
+					Source: noteID,
+					Target: stateID,
+					Geometry: &MxGeometry{
+						Relative: "1",
+						As:       "geometry",
+					},
+				})
+				cellID++
+			}
+		}
+	}
+	emit("", "1")
+
+	// Create transitions
+	for _, transition := range diagram.Transitions {
+		fromID := stateCells[transition.From]
+		toID := stateCells[transition.To]
+
+		if fromID == "" || toID == "" {
+			continue // Skip if state not found
+		}
+
+		cells = append(cells, MxCell{
+			ID:     fmt.Sprintf("transition_%d", cellID),
+			Value:  html.EscapeString(transition.Label),
+			Style:  "edgeStyle=orthogonalEdgeStyle;rounded=0;html=1;endArrow=open;endFill=0;endSize=8;",
+			Edge:   "1",
+			Parent: "1",
+			Source: fromID,
+			Target: toID,
+			Geometry: &MxGeometry{
+				Relative: "1",
+				As:       "geometry",
+			},
+		})
+		cellID++
+	}
+
+	model.Root.MxCells = cells
+	return generateXMLOutput(model)
+}
+
+// stateLayoutParent returns the container a state is laid out in: its
+// composite parent, or the parent's region when the parent is concurrent.
+func stateLayoutParent(state mermaid.State, regions map[string]int) string {
+	if state.Parent == "" {
+		return ""
+	}
+	if regions[state.Parent] > 1 {
+		return stateRegionID(state.Parent, state.Region)
+	}
+	return state.Parent
+}
+
+func stateRegionID(parent string, region int) string {
+	return fmt.Sprintf("%s#region%d", parent, region)
+}
+
+func stateSize(state mermaid.State, direction string) (float64, float64) {
+	switch state.Kind {
+	case mermaid.StartState, mermaid.EndState:
+		return PseudoStateSize, PseudoStateSize
+	case mermaid.ChoiceState:
+		return ChoiceStateSize, ChoiceStateSize
+	case mermaid.ForkState, mermaid.JoinState:
+		// The bar lies across the flow direction
+		if direction == "LR" || direction == "RL" {
+			return ForkBarThickness, ForkBarLength
+		}
+		return ForkBarLength, ForkBarThickness
+	default:
+		width := max(StateWidth, float64(utf8.RuneCountInString(state.Label))*StateCharWidth+2*StateCharWidth)
+		return width, StateHeight
+	}
+}
+
+func stateNoteSize(note mermaid.StateNote) (float64, float64) {
+	lines := strings.Split(note.Text, "\n")
+	longest := 0
+	for _, line := range lines {
+		longest = max(longest, utf8.RuneCountInString(line))
+	}
+	width := max(StateNoteWidth, float64(longest)*StateCharWidth+2*StateCharWidth)
+	return width, float64(len(lines))*StateNoteLineHeight + StateNoteLineHeight
+}
+
+// stateValue leaves pseudo-states and bars unlabelled.
+func stateValue(state mermaid.State) string {
+	switch state.Kind {
+	case mermaid.SimpleState:
+		return html.EscapeString(state.Label)
+	default:
+		return ""
+	}
+}
+
+func stateStyle(kind mermaid.StateKind) string {
+	switch kind {
+	case mermaid.StartState:
+		return "ellipse;html=1;shape=startState;fillColor=#000000;strokeColor=#000000;"
+	case mermaid.EndState:
+		return "ellipse;html=1;shape=endState;fillColor=#000000;strokeColor=#000000;"
+	case mermaid.ChoiceState:
+		return "rhombus;whiteSpace=wrap;html=1;"
+	case mermaid.ForkState, mermaid.JoinState:
+		return "rounded=0;whiteSpace=wrap;html=1;fillColor=#000000;strokeColor=none;"
+	default:
+		return "rounded=1;whiteSpace=wrap;html=1;arcSize=40;"
+	}
+}
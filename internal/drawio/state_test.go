@@ -0,0 +1,96 @@
+package drawio
+
+import (
+	"mermaid2drawio/internal/mermaid"
+	"strings"
+	"testing"
+)
+
+func TestGenerateStateDrawIOXML(t *testing.T) {
+	diagram := &mermaid.StateDiagram{
+		Direction: "TB",
+		States: []mermaid.State{
+			{ID: "[*]start", Kind: mermaid.StartState},
+			{ID: "Active", Label: "Active <on>", Kind: mermaid.CompositeState},
+			{ID: "On", Label: "On & ready", Parent: "Active"},
+			{ID: "Caps", Label: "Caps", Parent: "Active", Region: 1},
+			{ID: "check", Kind: mermaid.ChoiceState},
+			{ID: "bar", Kind: mermaid.ForkState},
+			{ID: "[*]end", Kind: mermaid.EndState},
+		},
+		Transitions: []mermaid.StateTransition{
+			{From: "[*]start", To: "Active"},
+			{From: "Active", To: "check", Label: "done <ok>"},
+			{From: "check", To: "bar"},
+			{From: "bar", To: "[*]end"},
+		},
+		Notes: []mermaid.StateNote{
+			{State: "On", Position: "right", Text: "a & b"},
+		},
+	}
+
+	xml, err := GenerateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"shape=startState;",
+		"shape=endState;",
+		"rhombus;",
+		"fillColor=#000000;strokeColor=none;",
+		"swimlane;rounded=1;",
+		"dashed=1;fillColor=none;",
+		"shape=note;",
+		"a &amp;amp; b",
+		"endArrow=open;",
+		`value="Active &amp;lt;on&amp;gt;"`,
+		`value="On &amp;amp; ready"`,
+		`value="done &amp;lt;ok&amp;gt;"`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML should contain %q", want)
+		}
+	}
+
+	// Two concurrent regions are emitted for Active
+	if count := strings.Count(xml, `id="region_`); count != 2 {
+		t.Errorf("Expected 2 regions, got %d", count)
+	}
+}
+
+func TestStateNoteOnCompositeInRegion(t *testing.T) {
+	diagram := &mermaid.StateDiagram{
+		Direction: "TB",
+		States: []mermaid.State{
+			{ID: "Outer", Label: "Outer", Kind: mermaid.CompositeState},
+			{ID: "Left", Label: "Left", Parent: "Outer"},
+			{ID: "Inner", Label: "Inner", Kind: mermaid.CompositeState, Parent: "Outer", Region: 1},
+			{ID: "Deep", Label: "Deep", Parent: "Inner"},
+			{ID: "Other", Label: "Other", Parent: "Outer", Region: 1},
+		},
+		Notes: []mermaid.StateNote{
+			{State: "Inner", Position: "right", Text: "inside"},
+		},
+	}
+
+	xml, err := GenerateStateDrawIOXML(diagram)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The note shares Inner's region and sits in the room kept beside Inner,
+	// clear of Other which starts at x=380
+	if !strings.Contains(xml, `id="state_6" value="Inner"`) || !strings.Contains(xml, `id="region_5"`) {
+		t.Fatalf("Unexpected cells in %s", xml)
+	}
+	if !strings.Contains(xml, `value="inside" style="shape=note;whiteSpace=wrap;html=1;backgroundOutline=1;size=14;align=left;spacingLeft=6;" vertex="1" parent="region_5"`) {
+		t.Errorf("Note should be placed in Inner's region")
+	}
+	if !strings.Contains(xml, `<mxGeometry x="200" y="20" width="140" height="36" as="geometry">`) {
+		t.Errorf("Note should sit right of Inner relative to the region")
+	}
+	if !strings.Contains(xml, `<mxGeometry x="380" y="55" width="120" height="40" as="geometry">`) {
+		t.Errorf("Other should be laid out past the note")
+	}
+}
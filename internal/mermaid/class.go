@@ -0,0 +1,330 @@
+package mermaid
+
+import (
+	"bufio"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type ClassDiagram struct {
+	Direction  string
+	Classes    []Class
+	Relations  []ClassRelation
+	Namespaces []string
+}
+
+func (cd *ClassDiagram) GetType() DiagramType {
+	return ClassDiagramType
+}
+
+type Class struct {
+	Name        string
+	Generic     string   // type parameter of "class Square~Shape~"
+	Annotations []string // e.g. "interface" for <<interface>>
+	Namespace   string
+	Fields      []ClassMember
+	Methods     []ClassMember
+}
+
+type ClassMember struct {
+	Name       string
+	Type       string // field type or method return type
+	Parameters string // raw method parameter list
+	Visibility Visibility
+	IsStatic   bool
+	IsAbstract bool
+}
+
+type Visibility int
+
+const (
+	NoVisibility Visibility = iota
+	PublicVisibility
+	PrivateVisibility
+	ProtectedVisibility
+	PackageVisibility
+)
+
+// ClassRelation connects From (left of the arrow) to To (right of the
+// arrow). FromEnd and ToEnd describe the marker drawn at each side.
+type ClassRelation struct {
+	From            string
+	To              string
+	FromEnd         RelationEnd
+	ToEnd           RelationEnd
+	Dashed          bool
+	FromCardinality string
+	ToCardinality   string
+	Label           string
+}
+
+type RelationEnd int
+
+const (
+	PlainEnd       RelationEnd = iota
+	InheritanceEnd             // <| or |>
+	CompositionEnd             // *
+	AggregationEnd             // o
+	AssociationEnd             // < or >
+)
+
+var (
+	classDiagramHeaderRegex = regexp.MustCompile(`^classDiagram(?:-v2)?\b`)
+	classNamePattern        = `([A-Za-z_][\w]*(?:~[^~\s]+(?:~[^~\s]*)*~)?)`
+	classRelationRegex      = regexp.MustCompile(`^` + classNamePattern + `\s*(?:"([^"]*)"\s*)?(<\||\*|o|<)?(--|\.\.)(\|>|\*|o|>)?\s*(?:"([^"]*)"\s*)?` + classNamePattern + `\s*(?::\s*(.*))?$`)
+	classDeclarationRegex   = regexp.MustCompile(`^class\s+` + classNamePattern + `(?:\s*\[\s*"?[^\]]*?"?\s*\])?(?::::\w+)?\s*(\{\s*\}?)?\s*$`)
+	classMemberLineRegex    = regexp.MustCompile(`^` + classNamePattern + `\s*:\s*(.+)$`)
+	classAnnotationRegex    = regexp.MustCompile(`^<<\s*([^>]+?)\s*>>\s*(\w+)?$`)
+	namespaceRegex          = regexp.MustCompile(`^namespace\s+([\w.]+)\s*\{$`)
+	classDirectionRegex     = regexp.MustCompile(`^direction\s+(TB|TD|BT|RL|LR)$`)
+)
+
+type classParser struct {
+	diagram      *ClassDiagram
+	classIndex   map[string]int
+	namespace    string
+	currentClass string
+}
+
+func ParseClassDiagram(input string) (*ClassDiagram, error) {
+	diagram := &ClassDiagram{
+		Direction:  "TB",
+		Classes:    make([]Class, 0),
+		Relations:  make([]ClassRelation, 0),
+		Namespaces: make([]string, 0),
+	}
+	parser := &classParser{
+		diagram:    diagram,
+		classIndex: make(map[string]int),
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "%%") || classDiagramHeaderRegex.MatchString(line) {
+			continue
+		}
+
+		parser.parseLine(line)
+	}
+
+	return diagram, scanner.Err()
+}
+
+func (p *classParser) parseLine(line string) {
+	if p.currentClass != "" {
+		if line == "}" {
+			p.currentClass = ""
+			return
+		}
+		if matches := classAnnotationRegex.FindStringSubmatch(line); matches != nil {
+			p.addAnnotation(p.currentClass, matches[1])
+			return
+		}
+		p.addMember(p.currentClass, line)
+		return
+	}
+
+	if line == "}" {
+		p.namespace = ""
+		return
+	}
+
+	if matches := namespaceRegex.FindStringSubmatch(line); matches != nil {
+		p.namespace = matches[1]
+		if !slices.Contains(p.diagram.Namespaces, p.namespace) {
+			p.diagram.Namespaces = append(p.diagram.Namespaces, p.namespace)
+		}
+		return
+	}
+
+	if matches := classDirectionRegex.FindStringSubmatch(line); matches != nil {
+		p.diagram.Direction = normalizeDirection(matches[1])
+		return
+	}
+
+	if matches := classDeclarationRegex.FindStringSubmatch(line); matches != nil {
+		name := p.ensureClass(matches[1])
+		if matches[2] != "" && !strings.HasSuffix(matches[2], "}") {
+			p.currentClass = name
+		}
+		return
+	}
+
+	if matches := classAnnotationRegex.FindStringSubmatch(line); matches != nil {
+		if matches[2] != "" {
+			p.addAnnotation(p.ensureClass(matches[2]), matches[1])
+		}
+		return
+	}
+
+	if relation := parseClassRelation(line); relation != nil {
+		relation.From = p.ensureClass(relation.From)
+		relation.To = p.ensureClass(relation.To)
+		p.diagram.Relations = append(p.diagram.Relations, *relation)
+		return
+	}
+
+	if matches := classMemberLineRegex.FindStringSubmatch(line); matches != nil {
+		p.addMember(p.ensureClass(matches[1]), matches[2])
+	}
+}
+
+// ensureClass registers a class on first reference and returns its name
+// without the generic suffix. Classes declared inside a namespace block are
+// assigned to it.
+func (p *classParser) ensureClass(reference string) string {
+	name, generic := splitGeneric(reference)
+	if index, exists := p.classIndex[name]; exists {
+		class := &p.diagram.Classes[index]
+		if class.Generic == "" {
+			class.Generic = generic
+		}
+		if class.Namespace == "" {
+			class.Namespace = p.namespace
+		}
+		return name
+	}
+
+	p.classIndex[name] = len(p.diagram.Classes)
+	p.diagram.Classes = append(p.diagram.Classes, Class{
+		Name:      name,
+		Generic:   generic,
+		Namespace: p.namespace,
+		Fields:    make([]ClassMember, 0),
+		Methods:   make([]ClassMember, 0),
+	})
+	return name
+}
+
+func (p *classParser) addAnnotation(name, annotation string) {
+	class := &p.diagram.Classes[p.classIndex[name]]
+	class.Annotations = append(class.Annotations, annotation)
+}
+
+func (p *classParser) addMember(name, text string) {
+	member, isMethod := parseClassMember(text)
+	if member.Name == "" {
+		return
+	}
+
+	class := &p.diagram.Classes[p.classIndex[name]]
+	if isMethod {
+		class.Methods = append(class.Methods, member)
+	} else {
+		class.Fields = append(class.Fields, member)
+	}
+}
+
+// splitGeneric splits "Square~Shape~" into "Square" and "Shape".
+func splitGeneric(reference string) (string, string) {
+	index := strings.Index(reference, "~")
+	if index < 0 {
+		return reference, ""
+	}
+	return reference[:index], strings.TrimSuffix(reference[index+1:], "~")
+}
+
+func parseClassRelation(line string) *ClassRelation {
+	matches := classRelationRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil
+	}
+
+	return &ClassRelation{
+		From:            matches[1],
+		FromCardinality: matches[2],
+		FromEnd:         parseRelationEnd(matches[3]),
+		Dashed:          matches[4] == "..",
+		ToEnd:           parseRelationEnd(matches[5]),
+		ToCardinality:   matches[6],
+		To:              matches[7],
+		Label:           strings.TrimSpace(matches[8]),
+	}
+}
+
+func parseRelationEnd(marker string) RelationEnd {
+	switch marker {
+	case "<|", "|>":
+		return InheritanceEnd
+	case "*":
+		return CompositionEnd
+	case "o":
+		return AggregationEnd
+	case "<", ">":
+		return AssociationEnd
+	default:
+		return PlainEnd
+	}
+}
+
+// parseClassMember parses "+List~int~ items", "-name: String",
+// "+area()$ double" and "#draw(Canvas c)*" style members. The second result
+// reports whether the member is a method.
+func parseClassMember(text string) (ClassMember, bool) {
+	text = strings.TrimSpace(text)
+	member := ClassMember{}
+
+	if text != "" {
+		switch text[0] {
+		case '+':
+			member.Visibility = PublicVisibility
+		case '-':
+			member.Visibility = PrivateVisibility
+		case '#':
+			member.Visibility = ProtectedVisibility
+		case '~':
+			member.Visibility = PackageVisibility
+		}
+		if member.Visibility != NoVisibility {
+			text = strings.TrimSpace(text[1:])
+		}
+	}
+
+	text = member.takeClassifier(text)
+
+	open := strings.Index(text, "(")
+	close := strings.LastIndex(text, ")")
+	if open >= 0 && close > open {
+		member.Name = strings.TrimSpace(text[:open])
+		member.Parameters = strings.TrimSpace(text[open+1 : close])
+		rest := member.takeClassifier(strings.TrimSpace(text[close+1:]))
+		member.Type = strings.TrimSpace(strings.TrimPrefix(rest, ":"))
+		return member, true
+	}
+
+	if name, fieldType, found := strings.Cut(text, ":"); found {
+		member.Name = strings.TrimSpace(name)
+		member.Type = strings.TrimSpace(fieldType)
+		return member, false
+	}
+
+	fields := strings.Fields(text)
+	switch len(fields) {
+	case 0:
+	case 1:
+		member.Name = fields[0]
+	default:
+		member.Type = strings.Join(fields[:len(fields)-1], " ")
+		member.Name = fields[len(fields)-1]
+	}
+	return member, false
+}
+
+// takeClassifier strips a leading or trailing "$" (static) or "*" (abstract)
+// marker and records it on the member.
+func (m *ClassMember) takeClassifier(text string) string {
+	for _, marker := range []string{"$", "*"} {
+		if strings.HasPrefix(text, marker) || strings.HasSuffix(text, marker) {
+			text = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(text, marker), marker))
+			if marker == "$" {
+				m.IsStatic = true
+			} else {
+				m.IsAbstract = true
+			}
+		}
+	}
+	return text
+}
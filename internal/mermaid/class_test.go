@@ -0,0 +1,154 @@
+package mermaid
+
+import (
+	"testing"
+)
+
+func TestParseClassDiagram(t *testing.T) {
+	input := `classDiagram
+    class Animal {
+        <<abstract>>
+        +String name
+        -int age$
+        #List~String~ tags
+        +makeSound()* void
+        +getFriends(List~Animal~ others) List~Animal~
+    }
+    Duck : +swim()
+    Duck : ~int depth
+    <<interface>> Swimmer
+    class Square~Shape~`
+
+	diagram, err := ParseClassDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(diagram.Classes) != 4 {
+		t.Fatalf("Expected 4 classes, got %d", len(diagram.Classes))
+	}
+
+	animal := diagram.Classes[0]
+	if animal.Name != "Animal" || len(animal.Annotations) != 1 || animal.Annotations[0] != "abstract" {
+		t.Errorf("Unexpected Animal class: %+v", animal)
+	}
+
+	expectedFields := []ClassMember{
+		{Name: "name", Type: "String", Visibility: PublicVisibility},
+		{Name: "age", Type: "int", Visibility: PrivateVisibility, IsStatic: true},
+		{Name: "tags", Type: "List~String~", Visibility: ProtectedVisibility},
+	}
+	if len(animal.Fields) != len(expectedFields) {
+		t.Fatalf("Expected %d fields, got %d", len(expectedFields), len(animal.Fields))
+	}
+	for i, want := range expectedFields {
+		if animal.Fields[i] != want {
+			t.Errorf("Field %d: expected %+v, got %+v", i, want, animal.Fields[i])
+		}
+	}
+
+	expectedMethods := []ClassMember{
+		{Name: "makeSound", Type: "void", Visibility: PublicVisibility, IsAbstract: true},
+		{Name: "getFriends", Parameters: "List~Animal~ others", Type: "List~Animal~", Visibility: PublicVisibility},
+	}
+	if len(animal.Methods) != len(expectedMethods) {
+		t.Fatalf("Expected %d methods, got %d", len(expectedMethods), len(animal.Methods))
+	}
+	for i, want := range expectedMethods {
+		if animal.Methods[i] != want {
+			t.Errorf("Method %d: expected %+v, got %+v", i, want, animal.Methods[i])
+		}
+	}
+
+	duck := diagram.Classes[1]
+	if len(duck.Methods) != 1 || len(duck.Fields) != 1 || duck.Fields[0].Visibility != PackageVisibility {
+		t.Errorf("Unexpected Duck class: %+v", duck)
+	}
+
+	if swimmer := diagram.Classes[2]; swimmer.Name != "Swimmer" || len(swimmer.Annotations) != 1 || swimmer.Annotations[0] != "interface" {
+		t.Errorf("Unexpected Swimmer class: %+v", swimmer)
+	}
+
+	if square := diagram.Classes[3]; square.Name != "Square" || square.Generic != "Shape" {
+		t.Errorf("Unexpected Square class: %+v", square)
+	}
+}
+
+func TestParseClassRelations(t *testing.T) {
+	tests := []struct {
+		line     string
+		fromEnd  RelationEnd
+		toEnd    RelationEnd
+		dashed   bool
+		fromCard string
+		toCard   string
+		label    string
+	}{
+		{"A <|-- B", InheritanceEnd, PlainEnd, false, "", "", ""},
+		{"A *-- B", CompositionEnd, PlainEnd, false, "", "", ""},
+		{"A o-- B", AggregationEnd, PlainEnd, false, "", "", ""},
+		{"A --> B", PlainEnd, AssociationEnd, false, "", "", ""},
+		{"A ..> B", PlainEnd, AssociationEnd, true, "", "", ""},
+		{"A ..|> B", PlainEnd, InheritanceEnd, true, "", "", ""},
+		{"A -- B", PlainEnd, PlainEnd, false, "", "", ""},
+		{`A "1" --> "*" B : owns`, PlainEnd, AssociationEnd, false, "1", "*", "owns"},
+		{"A <|--|> B", InheritanceEnd, InheritanceEnd, false, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			diagram, err := ParseClassDiagram("classDiagram\n    " + tt.line)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(diagram.Relations) != 1 {
+				t.Fatalf("Expected 1 relation, got %d", len(diagram.Relations))
+			}
+
+			relation := diagram.Relations[0]
+			if relation.From != "A" || relation.To != "B" {
+				t.Errorf("Expected A to B, got %s to %s", relation.From, relation.To)
+			}
+			if relation.FromEnd != tt.fromEnd || relation.ToEnd != tt.toEnd || relation.Dashed != tt.dashed {
+				t.Errorf("Expected ends %v/%v dashed=%v, got %v/%v dashed=%v", tt.fromEnd, tt.toEnd, tt.dashed, relation.FromEnd, relation.ToEnd, relation.Dashed)
+			}
+			if relation.FromCardinality != tt.fromCard || relation.ToCardinality != tt.toCard || relation.Label != tt.label {
+				t.Errorf("Expected %q/%q/%q, got %q/%q/%q", tt.fromCard, tt.toCard, tt.label, relation.FromCardinality, relation.ToCardinality, relation.Label)
+			}
+		})
+	}
+}
+
+func TestParseClassNamespaces(t *testing.T) {
+	input := `classDiagram
+    namespace Shapes {
+        class Triangle
+        class Square {
+            +int side
+        }
+    }
+    class Canvas
+    Canvas --> Square`
+
+	diagram, err := ParseDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	classDiagram, ok := diagram.(*ClassDiagram)
+	if !ok {
+		t.Fatalf("Expected *ClassDiagram, got %T", diagram)
+	}
+
+	if len(classDiagram.Namespaces) != 1 || classDiagram.Namespaces[0] != "Shapes" {
+		t.Errorf("Expected namespace Shapes, got %v", classDiagram.Namespaces)
+	}
+
+	namespaces := map[string]string{"Triangle": "Shapes", "Square": "Shapes", "Canvas": ""}
+	for _, class := range classDiagram.Classes {
+		if class.Namespace != namespaces[class.Name] {
+			t.Errorf("Class %s: expected namespace %q, got %q", class.Name, namespaces[class.Name], class.Namespace)
+		}
+	}
+}
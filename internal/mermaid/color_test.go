@@ -0,0 +1,31 @@
+package mermaid
+
+import (
+	"testing"
+)
+
+func TestParseLeadingColor(t *testing.T) {
+	tests := []struct {
+		input string
+		color Color
+		rest  string
+		ok    bool
+	}{
+		{"Aqua Backend", Color{Hex: "#00FFFF", Opacity: 100}, "Backend", true},
+		{"rgb(200, 150, 255)", Color{Hex: "#C896FF", Opacity: 100}, "", true},
+		{"rgba(0,0,255,.1) Critical path", Color{Hex: "#0000FF", Opacity: 10}, "Critical path", true},
+		{"#abc Team", Color{Hex: "#AABBCC", Opacity: 100}, "Team", true},
+		{"transparent Group", Color{}, "Group", true},
+		{"Backend services", Color{}, "Backend services", false},
+		{"", Color{}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			color, rest, ok := parseLeadingColor(tt.input)
+			if color != tt.color || rest != tt.rest || ok != tt.ok {
+				t.Errorf("parseLeadingColor(%q) = %+v, %q, %v; want %+v, %q, %v", tt.input, color, rest, ok, tt.color, tt.rest, tt.ok)
+			}
+		})
+	}
+}
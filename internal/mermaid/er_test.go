@@ -131,19 +131,161 @@ func TestParseRelationshipSymbol(t *testing.T) {
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			relType, fromCard, toCard := parseRelationshipSymbol(tt.symbol)
+			rel := parseRelationship("A " + tt.symbol + " B : test")
+			if rel == nil {
+				t.Fatalf("Expected %q to parse as a relationship", tt.symbol)
+			}
 			
-			if relType != tt.expectedType {
-				t.Errorf("Expected type %v, got %v", tt.expectedType, relType)
+			if rel.Type != tt.expectedType {
+				t.Errorf("Expected type %v, got %v", tt.expectedType, rel.Type)
 			}
 			
-			if fromCard != tt.expectedFrom {
-				t.Errorf("Expected from cardinality %s, got %s", tt.expectedFrom, fromCard)
+			if rel.FromCardinality != tt.expectedFrom {
+				t.Errorf("Expected from cardinality %s, got %s", tt.expectedFrom, rel.FromCardinality)
 			}
 			
-			if toCard != tt.expectedTo {
-				t.Errorf("Expected to cardinality %s, got %s", tt.expectedTo, toCard)
+			if rel.ToCardinality != tt.expectedTo {
+				t.Errorf("Expected to cardinality %s, got %s", tt.expectedTo, rel.ToCardinality)
+			}
+		})
+	}
+}
+
+func TestParseCardinalities(t *testing.T) {
+	tests := []struct {
+		line           string
+		fromEnd        Cardinality
+		toEnd          Cardinality
+		identification Identification
+	}{
+		{"CUSTOMER ||--o{ ORDER : places", ExactlyOne, ZeroOrMore, Identifying},
+		{"ORDER }|..|| CUSTOMER : belongs", OneOrMore, ExactlyOne, NonIdentifying},
+		{"PERSON |o--|{ CAR : drives", ZeroOrOne, OneOrMore, Identifying},
+		{"CAR }o..o| PERSON : parked", ZeroOrMore, ZeroOrOne, NonIdentifying},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			rel := parseRelationship(tt.line)
+			if rel == nil {
+				t.Fatalf("Expected relationship for %q", tt.line)
+			}
+			if rel.FromEnd != tt.fromEnd || rel.ToEnd != tt.toEnd || rel.Identification != tt.identification {
+				t.Errorf("Expected %v/%v/%v, got %v/%v/%v", tt.fromEnd, tt.toEnd, tt.identification, rel.FromEnd, rel.ToEnd, rel.Identification)
+			}
+		})
+	}
+}
+
+func TestParseRelationshipAliases(t *testing.T) {
+	tests := []struct {
+		line           string
+		from           string
+		to             string
+		fromEnd        Cardinality
+		toEnd          Cardinality
+		identification Identification
+		label          string
+	}{
+		{`CUSTOMER "1" to "zero or more" ORDER : "places order"`, "CUSTOMER", "ORDER", ExactlyOne, ZeroOrMore, Identifying, "places order"},
+		{"PERSON only one optionally to zero or one CAR : drives", "PERSON", "CAR", ExactlyOne, ZeroOrOne, NonIdentifying, "drives"},
+		{"ORDER one or more to  only one CUSTOMER : belongs", "ORDER", "CUSTOMER", OneOrMore, ExactlyOne, Identifying, "belongs"},
+		{"TEAM many(0) .. many(1) PLAYER : has", "TEAM", "PLAYER", ZeroOrMore, OneOrMore, NonIdentifying, "has"},
+		{"A 1+ to 0+ B : links", "A", "B", OneOrMore, ZeroOrMore, Identifying, "links"},
+		{"A||--o{B : compact", "A", "B", ExactlyOne, ZeroOrMore, Identifying, "compact"},
+		{`EVENT ||--|| TIME : "starts at 10:30"`, "EVENT", "TIME", ExactlyOne, ExactlyOne, Identifying, "starts at 10:30"},
+		{"EVENT ||--|| TIME : ends: late", "EVENT", "TIME", ExactlyOne, ExactlyOne, Identifying, "ends: late"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			rel := parseRelationship(tt.line)
+			if rel == nil {
+				t.Fatalf("Expected relationship for %q", tt.line)
+			}
+			if rel.From != tt.from || rel.To != tt.to || rel.Label != tt.label {
+				t.Errorf("Expected %s -> %s %q, got %s -> %s %q", tt.from, tt.to, tt.label, rel.From, rel.To, rel.Label)
+			}
+			if rel.FromEnd != tt.fromEnd || rel.ToEnd != tt.toEnd || rel.Identification != tt.identification {
+				t.Errorf("Expected %v/%v/%v, got %v/%v/%v", tt.fromEnd, tt.toEnd, tt.identification, rel.FromEnd, rel.ToEnd, rel.Identification)
+			}
+		})
+	}
+
+	if rel := parseRelationship("A only one to B : missing cardinality"); rel != nil {
+		t.Errorf("Expected nil relationship, got %+v", rel)
+	}
+}
+
+func TestParseAttributeKeysCommentsAndTypes(t *testing.T) {
+	tests := []struct {
+		line string
+		want Attribute
+	}{
+		{`int id PK, FK "the owner"`, Attribute{Name: "id", Type: "int", IsPK: true, IsFK: true, Comment: "the owner"}},
+		{"string code PK UK", Attribute{Name: "code", Type: "string", IsPK: true, IsUnique: true}},
+		{`varchar(255) email UK "login name"`, Attribute{Name: "email", Type: "varchar(255)", IsUnique: true, Comment: "login name"}},
+		{"string[] tags", Attribute{Name: "tags", Type: "string[]"}},
+		{`list~int~ scores "latest first"`, Attribute{Name: "scores", Type: "list~int~", Comment: "latest first"}},
+		{"decimal(10,2) total", Attribute{Name: "total", Type: "decimal(10,2)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			attr := parseAttribute(tt.line)
+			if attr == nil {
+				t.Fatalf("Expected attribute for %q", tt.line)
+			}
+			if *attr != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, *attr)
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestParseEntityNamesAndAliases(t *testing.T) {
+	input := `erDiagram
+    CUSTOMER["Customer Account"] {
+        string name
+    }
+    line-item[Line Item]
+    "Order Archive" {}
+    CUSTOMER ||--o{ ORDER : places
+    ORDER ||--|{ line-item : contains
+    "Order Archive" |o..o| ORDER : keeps
+    ORDER {
+        int id PK
+    }`
+
+	diagram, err := ParseERDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []struct {
+		name       string
+		alias      string
+		attributes int
+	}{
+		{"CUSTOMER", "Customer Account", 1},
+		{"line-item", "Line Item", 0},
+		{"Order Archive", "", 0},
+		{"ORDER", "", 1},
+	}
+	if len(diagram.Entities) != len(want) {
+		t.Fatalf("Expected %d entities, got %d", len(want), len(diagram.Entities))
+	}
+	for i, w := range want {
+		entity := diagram.Entities[i]
+		if entity.Name != w.name || entity.Alias != w.alias || len(entity.Attributes) != w.attributes {
+			t.Errorf("Entity %d: expected %s %q with %d attributes, got %+v", i, w.name, w.alias, w.attributes, entity)
+		}
+	}
+
+	if len(diagram.Relationships) != 3 {
+		t.Fatalf("Expected 3 relationships, got %d", len(diagram.Relationships))
+	}
+	if rel := diagram.Relationships[2]; rel.From != "Order Archive" || rel.To != "ORDER" {
+		t.Errorf("Expected relationship from the quoted entity, got %s to %s", rel.From, rel.To)
+	}
+}
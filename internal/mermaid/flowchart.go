@@ -0,0 +1,554 @@
+package mermaid
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+type FlowchartDiagram struct {
+	Direction string
+	Nodes     []FlowNode
+	Edges     []FlowEdge
+	Subgraphs []Subgraph
+}
+
+func (fd *FlowchartDiagram) GetType() DiagramType {
+	return FlowchartDiagramType
+}
+
+type FlowNode struct {
+	ID       string
+	Label    string
+	Shape    NodeShape
+	Subgraph string // ID of the innermost enclosing subgraph, empty at top level
+}
+
+type Subgraph struct {
+	ID        string
+	Title     string
+	Parent    string // ID of the enclosing subgraph, empty at top level
+	Direction string // empty when the flowchart direction is inherited
+}
+
+type NodeShape int
+
+const (
+	RectangleShape        NodeShape = iota // A[text]
+	RoundedShape                           // A(text)
+	StadiumShape                           // A([text])
+	SubroutineShape                        // A[[text]]
+	CylinderShape                          // A[(text)]
+	CircleShape                            // A((text))
+	DoubleCircleShape                      // A(((text)))
+	AsymmetricShape                        // A>text]
+	RhombusShape                           // A{text}
+	HexagonShape                           // A{{text}}
+	ParallelogramShape                     // A[/text/]
+	ParallelogramAltShape                  // A[\text\]
+	TrapezoidShape                         // A[/text\]
+	TrapezoidAltShape                      // A[\text/]
+)
+
+type FlowEdge struct {
+	From       string
+	To         string
+	Label      string
+	Stroke     EdgeStroke
+	StartArrow ArrowHead
+	EndArrow   ArrowHead
+}
+
+type EdgeStroke int
+
+const (
+	SolidStroke     EdgeStroke = iota // ---
+	DottedStroke                      // -.-
+	ThickStroke                       // ===
+	InvisibleStroke                   // ~~~
+)
+
+type ArrowHead int
+
+const (
+	NoHead ArrowHead = iota
+	PointHead
+	CircleHead
+	CrossHead
+)
+
+var (
+	flowchartHeaderRegex = regexp.MustCompile(`^(flowchart|graph)(?:\s+(TB|TD|BT|RL|LR))?\b`)
+	flowLinkRegex        = regexp.MustCompile(`^([<ox]?)(-{2,}|={2,}|-\.+-|~{3,})([>ox]?)`)
+	flowTextLinkRegex    = regexp.MustCompile(`^([<ox]?)(--|==|-\.)\s*([^\s\-=.>].*?)\s*(-{2,}|={2,}|\.+-)([>ox]?)`)
+	subgraphRegex        = regexp.MustCompile(`^subgraph(?:\s+(.*))?$`)
+	subgraphTitleRegex   = regexp.MustCompile(`^([^\s\[]+)\s*\[(.*)\]$`)
+	flowDirectionRegex   = regexp.MustCompile(`^direction\s+(TB|TD|BT|RL|LR)$`)
+)
+
+// flowShapeDelimiters lists node shape brackets with the longest openers first
+// so that "((" is tried before "(". Entries sharing an opener are adjacent.
+var flowShapeDelimiters = []struct {
+	open  string
+	close string
+	shape NodeShape
+}{
+	{"(((", ")))", DoubleCircleShape},
+	{"((", "))", CircleShape},
+	{"([", "])", StadiumShape},
+	{"[[", "]]", SubroutineShape},
+	{"[(", ")]", CylinderShape},
+	{"[/", "/]", ParallelogramShape},
+	{"[/", `\]`, TrapezoidShape},
+	{`[\`, `\]`, ParallelogramAltShape},
+	{`[\`, "/]", TrapezoidAltShape},
+	{"{{", "}}", HexagonShape},
+	{"[", "]", RectangleShape},
+	{"(", ")", RoundedShape},
+	{"{", "}", RhombusShape},
+	{">", "]", AsymmetricShape},
+}
+
+type flowchartParser struct {
+	diagram       *FlowchartDiagram
+	nodeIndex     map[string]int
+	subgraphIndex map[string]int
+	subgraphStack []string
+}
+
+func ParseFlowchartDiagram(input string) (*FlowchartDiagram, error) {
+	diagram := &FlowchartDiagram{
+		Direction: "TB",
+		Nodes:     make([]FlowNode, 0),
+		Edges:     make([]FlowEdge, 0),
+		Subgraphs: make([]Subgraph, 0),
+	}
+	parser := &flowchartParser{
+		diagram:       diagram,
+		nodeIndex:     make(map[string]int),
+		subgraphIndex: make(map[string]int),
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "%%") {
+			continue
+		}
+
+		if header := flowchartHeaderRegex.FindStringSubmatch(line); header != nil {
+			if header[2] != "" {
+				diagram.Direction = normalizeDirection(header[2])
+			}
+			continue
+		}
+
+		for _, statement := range splitFlowStatements(line) {
+			parser.parseStatement(statement)
+		}
+	}
+
+	parser.removeSubgraphNodes()
+	return diagram, scanner.Err()
+}
+
+// normalizeDirection maps Mermaid's TD alias onto TB.
+func normalizeDirection(direction string) string {
+	if direction == "TD" {
+		return "TB"
+	}
+	return direction
+}
+
+// splitFlowStatements splits a line on ';' separators that are not inside a
+// quoted label.
+func splitFlowStatements(line string) []string {
+	var statements []string
+	inQuote := false
+	start := 0
+	for i, r := range line {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == ';' && !inQuote:
+			statements = append(statements, strings.TrimSpace(line[start:i]))
+			start = i + 1
+		}
+	}
+	statements = append(statements, strings.TrimSpace(line[start:]))
+	return statements
+}
+
+func shouldSkipFlowStatement(statement string) bool {
+	if statement == "" {
+		return true
+	}
+	keyword := strings.Fields(statement)[0]
+	switch keyword {
+	case "classDef", "class", "style", "linkStyle", "click":
+		return true
+	}
+	return false
+}
+
+// parseStatement handles a node declaration or a chain of links such as
+// "A & B --> C -.-> D". Every node of one group is linked to every node of
+// the next group.
+func (p *flowchartParser) parseStatement(statement string) {
+	if shouldSkipFlowStatement(statement) || p.parseSubgraphStatement(statement) {
+		return
+	}
+
+	cursor := &flowCursor{text: statement}
+	from := p.parseNodeGroup(cursor)
+	if len(from) == 0 {
+		return
+	}
+
+	for {
+		link, ok := cursor.readLink()
+		if !ok {
+			return
+		}
+		to := p.parseNodeGroup(cursor)
+		if len(to) == 0 {
+			return
+		}
+		for _, source := range from {
+			for _, target := range to {
+				edge := link
+				edge.From = source
+				edge.To = target
+				p.diagram.Edges = append(p.diagram.Edges, edge)
+			}
+		}
+		from = to
+	}
+}
+
+func (p *flowchartParser) parseNodeGroup(cursor *flowCursor) []string {
+	var ids []string
+	for {
+		id := p.parseNode(cursor)
+		if id == "" {
+			return ids
+		}
+		ids = append(ids, id)
+
+		cursor.skipSpaces()
+		if !cursor.consume("&") {
+			return ids
+		}
+	}
+}
+
+func (p *flowchartParser) parseNode(cursor *flowCursor) string {
+	cursor.skipSpaces()
+	id := cursor.readID()
+	if id == "" {
+		return ""
+	}
+
+	label, shape, hasShape := cursor.readShape()
+	cursor.skipClass()
+	p.addNode(id, label, shape, hasShape)
+	return id
+}
+
+// parseSubgraphStatement handles "subgraph", "end" and "direction" statements
+// and reports whether the statement was one of them.
+func (p *flowchartParser) parseSubgraphStatement(statement string) bool {
+	if statement == "end" {
+		if len(p.subgraphStack) > 0 {
+			p.subgraphStack = p.subgraphStack[:len(p.subgraphStack)-1]
+		}
+		return true
+	}
+
+	if matches := flowDirectionRegex.FindStringSubmatch(statement); matches != nil {
+		if current := p.currentSubgraph(); current != "" {
+			p.diagram.Subgraphs[p.subgraphIndex[current]].Direction = normalizeDirection(matches[1])
+		}
+		return true
+	}
+
+	matches := subgraphRegex.FindStringSubmatch(statement)
+	if matches == nil {
+		return false
+	}
+
+	id, title := parseSubgraphHeader(strings.TrimSpace(matches[1]))
+	if id == "" {
+		id = fmt.Sprintf("subgraph_%d", len(p.diagram.Subgraphs)+1)
+	}
+	if _, exists := p.subgraphIndex[id]; !exists {
+		p.subgraphIndex[id] = len(p.diagram.Subgraphs)
+		p.diagram.Subgraphs = append(p.diagram.Subgraphs, Subgraph{
+			ID:     id,
+			Title:  title,
+			Parent: p.currentSubgraph(),
+		})
+	}
+	p.subgraphStack = append(p.subgraphStack, id)
+	return true
+}
+
+// parseSubgraphHeader splits `id [Title]`, quoted and plain subgraph headers
+// into an id and a display title.
+func parseSubgraphHeader(header string) (string, string) {
+	if matches := subgraphTitleRegex.FindStringSubmatch(header); matches != nil {
+		return matches[1], cleanFlowLabel(matches[2])
+	}
+	if strings.HasPrefix(header, `"`) {
+		return "", cleanFlowLabel(header)
+	}
+	return header, header
+}
+
+func (p *flowchartParser) currentSubgraph() string {
+	if len(p.subgraphStack) == 0 {
+		return ""
+	}
+	return p.subgraphStack[len(p.subgraphStack)-1]
+}
+
+// addNode registers a node on first reference. A later reference that carries
+// a shape overrides the label and shape of a bare earlier reference. A node
+// belongs to the first subgraph it is referenced in.
+func (p *flowchartParser) addNode(id, label string, shape NodeShape, hasShape bool) {
+	if index, exists := p.nodeIndex[id]; exists {
+		if hasShape {
+			p.diagram.Nodes[index].Label = label
+			p.diagram.Nodes[index].Shape = shape
+		}
+		if p.diagram.Nodes[index].Subgraph == "" {
+			p.diagram.Nodes[index].Subgraph = p.currentSubgraph()
+		}
+		return
+	}
+
+	node := FlowNode{ID: id, Label: id, Shape: RectangleShape, Subgraph: p.currentSubgraph()}
+	if hasShape {
+		node.Label = label
+		node.Shape = shape
+	}
+	p.nodeIndex[id] = len(p.diagram.Nodes)
+	p.diagram.Nodes = append(p.diagram.Nodes, node)
+}
+
+// removeSubgraphNodes drops the implicit nodes created when an edge targets a
+// subgraph id; those edges connect to the subgraph itself.
+func (p *flowchartParser) removeSubgraphNodes() {
+	nodes := p.diagram.Nodes[:0]
+	for _, node := range p.diagram.Nodes {
+		if _, isSubgraph := p.subgraphIndex[node.ID]; !isSubgraph {
+			nodes = append(nodes, node)
+		}
+	}
+	p.diagram.Nodes = nodes
+}
+
+// flowCursor walks a single flowchart statement.
+type flowCursor struct {
+	text string
+	pos  int
+}
+
+func (c *flowCursor) rest() string {
+	return c.text[c.pos:]
+}
+
+func (c *flowCursor) skipSpaces() {
+	for c.pos < len(c.text) && (c.text[c.pos] == ' ' || c.text[c.pos] == '\t') {
+		c.pos++
+	}
+}
+
+func (c *flowCursor) consume(prefix string) bool {
+	if strings.HasPrefix(c.rest(), prefix) {
+		c.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (c *flowCursor) readID() string {
+	end := c.pos
+	for i, r := range c.rest() {
+		if !isFlowIDRune(r) {
+			break
+		}
+		end = c.pos + i + len(string(r))
+	}
+	id := c.text[c.pos:end]
+	c.pos = end
+	return id
+}
+
+func isFlowIDRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (c *flowCursor) skipClass() {
+	if c.consume(":::") {
+		c.readID()
+	}
+}
+
+// readShape reads a bracketed label directly following a node id.
+func (c *flowCursor) readShape() (string, NodeShape, bool) {
+	rest := c.rest()
+	matchedOpen := ""
+	bestEnd := -1
+	var bestShape NodeShape
+	bestLabel := ""
+
+	// A longer opener without its closer falls back to the shorter ones, so
+	// A[/api/v1] is a rectangle labelled "/api/v1"
+	for _, delim := range flowShapeDelimiters {
+		if matchedOpen != "" && delim.open != matchedOpen && bestEnd >= 0 {
+			break
+		}
+		if !strings.HasPrefix(rest, delim.open) {
+			continue
+		}
+		matchedOpen = delim.open
+
+		label, end := findShapeClose(rest, len(delim.open), delim.close)
+		if end >= 0 && (bestEnd < 0 || end < bestEnd) {
+			bestEnd = end
+			bestShape = delim.shape
+			bestLabel = label
+		}
+	}
+
+	if bestEnd < 0 {
+		return "", RectangleShape, false
+	}
+	c.pos += bestEnd
+	return bestLabel, bestShape, true
+}
+
+// findShapeClose locates closer after the opening bracket, skipping over a
+// quoted label, and returns the label together with the offset just past the
+// closer.
+func findShapeClose(text string, start int, closer string) (string, int) {
+	body := text[start:]
+	searchFrom := 0
+	if trimmed := strings.TrimLeft(body, " "); strings.HasPrefix(trimmed, `"`) {
+		quoteStart := len(body) - len(trimmed)
+		quoteEnd := strings.Index(body[quoteStart+1:], `"`)
+		if quoteEnd >= 0 {
+			searchFrom = quoteStart + 1 + quoteEnd + 1
+		}
+	}
+
+	index := strings.Index(body[searchFrom:], closer)
+	if index < 0 {
+		return "", -1
+	}
+	index += searchFrom
+	return cleanFlowLabel(body[:index]), start + index + len(closer)
+}
+
+func cleanFlowLabel(label string) string {
+	label = strings.TrimSpace(label)
+	if len(label) >= 2 && strings.HasPrefix(label, `"`) && strings.HasSuffix(label, `"`) {
+		label = label[1 : len(label)-1]
+	}
+	return label
+}
+
+// readLink reads a link such as "-->", "-.->", "==>", "-- text -->" or
+// "--o" together with an optional trailing "|text|" label.
+func (c *flowCursor) readLink() (FlowEdge, bool) {
+	c.skipSpaces()
+	rest := c.rest()
+
+	edge, length, ok := parsePlainLink(rest)
+	if !ok {
+		edge, length, ok = parseTextLink(rest)
+	}
+	if !ok {
+		return FlowEdge{}, false
+	}
+	c.pos += length
+
+	c.skipSpaces()
+	if strings.HasPrefix(c.rest(), "|") {
+		if end := strings.Index(c.rest()[1:], "|"); end >= 0 {
+			edge.Label = cleanFlowLabel(c.rest()[1 : end+1])
+			c.pos += end + 2
+		}
+	}
+	return edge, true
+}
+
+func parsePlainLink(text string) (FlowEdge, int, bool) {
+	matches := flowLinkRegex.FindStringSubmatch(text)
+	if matches == nil {
+		return FlowEdge{}, 0, false
+	}
+
+	start, body, end := matches[1], matches[2], matches[3]
+	length := len(matches[0])
+
+	// "--xyz" is the start of a labelled link rather than a cross head.
+	if (end == "o" || end == "x") && length < len(text) && isFlowIDRune(rune(text[length])) {
+		end = ""
+		length--
+	}
+	// A bare "--" or "==" only opens a labelled link.
+	if start == "" && end == "" && len(body) < 3 {
+		return FlowEdge{}, 0, false
+	}
+
+	return FlowEdge{
+		Stroke:     linkStroke(body),
+		StartArrow: linkArrowHead(start),
+		EndArrow:   linkArrowHead(end),
+	}, length, true
+}
+
+func parseTextLink(text string) (FlowEdge, int, bool) {
+	matches := flowTextLinkRegex.FindStringSubmatch(text)
+	if matches == nil {
+		return FlowEdge{}, 0, false
+	}
+
+	return FlowEdge{
+		Label:      cleanFlowLabel(matches[3]),
+		Stroke:     linkStroke(matches[2]),
+		StartArrow: linkArrowHead(matches[1]),
+		EndArrow:   linkArrowHead(matches[5]),
+	}, len(matches[0]), true
+}
+
+func linkStroke(body string) EdgeStroke {
+	switch {
+	case strings.Contains(body, "~"):
+		return InvisibleStroke
+	case strings.Contains(body, "="):
+		return ThickStroke
+	case strings.Contains(body, "."):
+		return DottedStroke
+	default:
+		return SolidStroke
+	}
+}
+
+func linkArrowHead(marker string) ArrowHead {
+	switch marker {
+	case ">", "<":
+		return PointHead
+	case "o":
+		return CircleHead
+	case "x":
+		return CrossHead
+	default:
+		return NoHead
+	}
+}
@@ -0,0 +1,253 @@
+package mermaid
+
+import (
+	"testing"
+)
+
+func TestParseFlowchartNodeShapes(t *testing.T) {
+	input := `flowchart TD
+    A[Rectangle]
+    B(Rounded)
+    C([Stadium])
+    D[[Subroutine]]
+    E[(Database)]
+    F((Circle))
+    G(((Double)))
+    H>Flag]
+    I{Decision}
+    J{{Hexagon}}
+    K[/Input/]
+    L[\Output\]
+    M[/Trapezoid\]
+    N[\Alt/]
+    O["Quoted (label)"]
+    P[/api/v1]
+    Q[\share]`
+
+	diagram, err := ParseFlowchartDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		id    string
+		label string
+		shape NodeShape
+	}{
+		{"A", "Rectangle", RectangleShape},
+		{"B", "Rounded", RoundedShape},
+		{"C", "Stadium", StadiumShape},
+		{"D", "Subroutine", SubroutineShape},
+		{"E", "Database", CylinderShape},
+		{"F", "Circle", CircleShape},
+		{"G", "Double", DoubleCircleShape},
+		{"H", "Flag", AsymmetricShape},
+		{"I", "Decision", RhombusShape},
+		{"J", "Hexagon", HexagonShape},
+		{"K", "Input", ParallelogramShape},
+		{"L", "Output", ParallelogramAltShape},
+		{"M", "Trapezoid", TrapezoidShape},
+		{"N", "Alt", TrapezoidAltShape},
+		{"O", "Quoted (label)", RectangleShape},
+		{"P", "/api/v1", RectangleShape},
+		{"Q", `\share`, RectangleShape},
+	}
+
+	if len(diagram.Nodes) != len(expected) {
+		t.Fatalf("Expected %d nodes, got %d", len(expected), len(diagram.Nodes))
+	}
+
+	for i, want := range expected {
+		node := diagram.Nodes[i]
+		if node.ID != want.id || node.Label != want.label || node.Shape != want.shape {
+			t.Errorf("Node %d: expected %s/%q/%v, got %s/%q/%v", i, want.id, want.label, want.shape, node.ID, node.Label, node.Shape)
+		}
+	}
+}
+
+func TestParseFlowchartEdges(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		label      string
+		stroke     EdgeStroke
+		startArrow ArrowHead
+		endArrow   ArrowHead
+	}{
+		{"arrow", "A --> B", "", SolidStroke, NoHead, PointHead},
+		{"open", "A --- B", "", SolidStroke, NoHead, NoHead},
+		{"dotted", "A -.-> B", "", DottedStroke, NoHead, PointHead},
+		{"thick", "A ==> B", "", ThickStroke, NoHead, PointHead},
+		{"text", "A -- yes --> B", "yes", SolidStroke, NoHead, PointHead},
+		{"compact text", "A--text-->B", "text", SolidStroke, NoHead, PointHead},
+		{"pipe text", "A -->|no| B", "no", SolidStroke, NoHead, PointHead},
+		{"dotted text", "A -. maybe .-> B", "maybe", DottedStroke, NoHead, PointHead},
+		{"thick text", "A == sure ==> B", "sure", ThickStroke, NoHead, PointHead},
+		{"circle", "A --o B", "", SolidStroke, NoHead, CircleHead},
+		{"cross", "A --x B", "", SolidStroke, NoHead, CrossHead},
+		{"bidirectional", "A <--> B", "", SolidStroke, PointHead, PointHead},
+		{"invisible", "A ~~~ B", "", InvisibleStroke, NoHead, NoHead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := ParseFlowchartDiagram("flowchart LR\n    " + tt.line)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(diagram.Edges) != 1 {
+				t.Fatalf("Expected 1 edge, got %d", len(diagram.Edges))
+			}
+
+			edge := diagram.Edges[0]
+			if edge.From != "A" || edge.To != "B" {
+				t.Errorf("Expected edge A to B, got %s to %s", edge.From, edge.To)
+			}
+			if edge.Label != tt.label {
+				t.Errorf("Expected label %q, got %q", tt.label, edge.Label)
+			}
+			if edge.Stroke != tt.stroke {
+				t.Errorf("Expected stroke %v, got %v", tt.stroke, edge.Stroke)
+			}
+			if edge.StartArrow != tt.startArrow || edge.EndArrow != tt.endArrow {
+				t.Errorf("Expected arrows %v/%v, got %v/%v", tt.startArrow, tt.endArrow, edge.StartArrow, edge.EndArrow)
+			}
+		})
+	}
+}
+
+func TestParseFlowchartChainsAndFanOut(t *testing.T) {
+	input := `graph LR
+    A --> B --> C
+    C & D --> E & F; F --> G`
+
+	diagram, err := ParseFlowchartDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if diagram.Direction != "LR" {
+		t.Errorf("Expected direction LR, got %s", diagram.Direction)
+	}
+
+	if len(diagram.Nodes) != 7 {
+		t.Errorf("Expected 7 nodes, got %d", len(diagram.Nodes))
+	}
+
+	expected := [][2]string{
+		{"A", "B"}, {"B", "C"},
+		{"C", "E"}, {"C", "F"}, {"D", "E"}, {"D", "F"},
+		{"F", "G"},
+	}
+	if len(diagram.Edges) != len(expected) {
+		t.Fatalf("Expected %d edges, got %d", len(expected), len(diagram.Edges))
+	}
+	for i, want := range expected {
+		if diagram.Edges[i].From != want[0] || diagram.Edges[i].To != want[1] {
+			t.Errorf("Edge %d: expected %s to %s, got %s to %s", i, want[0], want[1], diagram.Edges[i].From, diagram.Edges[i].To)
+		}
+	}
+}
+
+func TestParseFlowchartNodeRedefinition(t *testing.T) {
+	input := `flowchart TD
+    A --> B
+    B{Is it?}:::decision
+    A[Start]`
+
+	diagram, err := ParseFlowchartDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(diagram.Nodes) != 2 {
+		t.Fatalf("Expected 2 nodes, got %d", len(diagram.Nodes))
+	}
+	if diagram.Nodes[0].Label != "Start" {
+		t.Errorf("Expected A label Start, got %q", diagram.Nodes[0].Label)
+	}
+	if diagram.Nodes[1].Shape != RhombusShape || diagram.Nodes[1].Label != "Is it?" {
+		t.Errorf("Expected B to be a rhombus labelled 'Is it?', got %v %q", diagram.Nodes[1].Shape, diagram.Nodes[1].Label)
+	}
+	if diagram.Direction != "TB" {
+		t.Errorf("Expected TD to normalize to TB, got %s", diagram.Direction)
+	}
+}
+
+func TestDetectFlowchartDiagramType(t *testing.T) {
+	for _, input := range []string{"flowchart TD\n    A --> B", "graph LR\n    A --> B", "flowchart\n    A"} {
+		if got := DetectDiagramType(input); got != FlowchartDiagramType {
+			t.Errorf("Expected FlowchartDiagramType for %q, got %v", input, got)
+		}
+
+		diagram, err := ParseDiagram(input)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, ok := diagram.(*FlowchartDiagram); !ok {
+			t.Errorf("Expected *FlowchartDiagram for %q, got %T", input, diagram)
+		}
+	}
+}
+
+func TestParseFlowchartSubgraphs(t *testing.T) {
+	input := `flowchart LR
+    client --> api
+    subgraph backend [Backend Services]
+        direction TB
+        api --> worker
+        subgraph storage
+            db[(DB)]
+        end
+        worker --> db
+    end
+    subgraph "Monitoring"
+        logs
+    end
+    client --> storage`
+
+	diagram, err := ParseFlowchartDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(diagram.Subgraphs) != 3 {
+		t.Fatalf("Expected 3 subgraphs, got %d", len(diagram.Subgraphs))
+	}
+
+	backend := diagram.Subgraphs[0]
+	if backend.ID != "backend" || backend.Title != "Backend Services" || backend.Direction != "TB" || backend.Parent != "" {
+		t.Errorf("Unexpected backend subgraph: %+v", backend)
+	}
+
+	storage := diagram.Subgraphs[1]
+	if storage.ID != "storage" || storage.Title != "storage" || storage.Parent != "backend" {
+		t.Errorf("Unexpected storage subgraph: %+v", storage)
+	}
+
+	if monitoring := diagram.Subgraphs[2]; monitoring.Title != "Monitoring" || monitoring.ID == "" {
+		t.Errorf("Unexpected monitoring subgraph: %+v", monitoring)
+	}
+
+	membership := map[string]string{
+		"client": "",
+		"api":    "backend",
+		"worker": "backend",
+		"db":     "storage",
+		"logs":   diagram.Subgraphs[2].ID,
+	}
+	if len(diagram.Nodes) != len(membership) {
+		t.Fatalf("Expected %d nodes, got %d: %+v", len(membership), len(diagram.Nodes), diagram.Nodes)
+	}
+	for _, node := range diagram.Nodes {
+		if node.Subgraph != membership[node.ID] {
+			t.Errorf("Node %s: expected subgraph %q, got %q", node.ID, membership[node.ID], node.Subgraph)
+		}
+	}
+
+	last := diagram.Edges[len(diagram.Edges)-1]
+	if last.From != "client" || last.To != "storage" {
+		t.Errorf("Expected edge to the storage subgraph, got %s to %s", last.From, last.To)
+	}
+}
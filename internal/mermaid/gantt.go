@@ -0,0 +1,429 @@
+package mermaid
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type GanttDiagram struct {
+	Title       string
+	DateFormat  string // dayjs-style input format, e.g. "YYYY-MM-DD"
+	AxisFormat  string // strftime-style axis label format, e.g. "%m/%d"
+	TodayMarker string // "off", a CSS-like style, or "" for the default marker
+	Sections    []string
+	Tasks       []GanttTask
+
+	ExcludeWeekends  bool
+	ExcludedWeekdays []time.Weekday
+	ExcludedDates    []time.Time
+}
+
+func (gd *GanttDiagram) GetType() DiagramType {
+	return GanttDiagramType
+}
+
+// IsExcluded reports whether the day holding t is skipped by the excludes
+// statement.
+func (gd *GanttDiagram) IsExcluded(t time.Time) bool {
+	weekday := t.Weekday()
+	if gd.ExcludeWeekends && (weekday == time.Saturday || weekday == time.Sunday) {
+		return true
+	}
+	if slices.Contains(gd.ExcludedWeekdays, weekday) {
+		return true
+	}
+	year, month, day := t.Date()
+	for _, date := range gd.ExcludedDates {
+		y, m, d := date.Date()
+		if y == year && m == month && d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// GanttTask is a scheduled bar. End is exclusive; milestones usually have
+// End equal to Start.
+type GanttTask struct {
+	ID        string
+	Name      string
+	Section   string
+	Start     time.Time
+	End       time.Time
+	After     []string // ids of the tasks this one starts after
+	Done      bool
+	Active    bool
+	Critical  bool
+	Milestone bool
+}
+
+const defaultGanttDateFormat = "YYYY-MM-DD"
+
+var (
+	ganttHeaderRegex   = regexp.MustCompile(`^gantt\b`)
+	ganttKeywordRegex  = regexp.MustCompile(`^(title|dateFormat|axisFormat|excludes|todayMarker|section)\s+(.+)$`)
+	ganttIgnoredRegex  = regexp.MustCompile(`^(includes|inclusiveEndDates|tickInterval|weekday|weekend|topAxis|displayMode|accTitle|accDescr|click)\b`)
+	ganttTaskRegex     = regexp.MustCompile(`^([^:]+?)\s*:\s*(.+)$`)
+	ganttDurationRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)(ms|s|m|h|d|w)$`)
+	dayjsTokenRegex    = regexp.MustCompile(`\[[^\]]*\]|YYYY|YY|MM|M|DD|D|HH|H|hh|h|mm|m|ss|s|SSS|A|a|ZZ|Z`)
+)
+
+var ganttTags = map[string]bool{"done": true, "active": true, "crit": true, "milestone": true}
+
+var dayjsLayouts = map[string]string{
+	"YYYY": "2006",
+	"YY":   "06",
+	"MM":   "01",
+	"M":    "1",
+	"DD":   "02",
+	"D":    "2",
+	"HH":   "15",
+	"H":    "15",
+	"hh":   "03",
+	"h":    "3",
+	"mm":   "04",
+	"m":    "4",
+	"ss":   "05",
+	"s":    "5",
+	"SSS":  "000",
+	"A":    "PM",
+	"a":    "pm",
+	"ZZ":   "-0700",
+	"Z":    "-07:00",
+}
+
+// ganttTaskSpec keeps the unresolved start and end of a task until every
+// task is known, so "after" may refer to tasks declared later.
+type ganttTaskSpec struct {
+	line  int
+	start string
+	end   string
+}
+
+type ganttParser struct {
+	diagram   *GanttDiagram
+	specs     []ganttTaskSpec
+	taskIndex map[string]int
+	excludes  []string
+}
+
+func ParseGanttDiagram(input string) (*GanttDiagram, error) {
+	diagram := &GanttDiagram{
+		DateFormat: defaultGanttDateFormat,
+		Sections:   make([]string, 0),
+		Tasks:      make([]GanttTask, 0),
+	}
+	parser := &ganttParser{
+		diagram:   diagram,
+		taskIndex: make(map[string]int),
+	}
+	section := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "%%") || ganttHeaderRegex.MatchString(line) || ganttIgnoredRegex.MatchString(line) {
+			continue
+		}
+
+		if match := ganttKeywordRegex.FindStringSubmatch(line); match != nil {
+			value := strings.TrimSpace(match[2])
+			switch match[1] {
+			case "title":
+				diagram.Title = value
+			case "dateFormat":
+				diagram.DateFormat = value
+			case "axisFormat":
+				diagram.AxisFormat = value
+			case "excludes":
+				for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+					parser.excludes = append(parser.excludes, field)
+				}
+			case "todayMarker":
+				diagram.TodayMarker = value
+			case "section":
+				section = value
+				if !slices.Contains(diagram.Sections, section) {
+					diagram.Sections = append(diagram.Sections, section)
+				}
+			}
+			continue
+		}
+
+		if match := ganttTaskRegex.FindStringSubmatch(line); match != nil {
+			if err := parser.addTask(match[1], match[2], section, lineNumber); err != nil {
+				return diagram, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return diagram, err
+	}
+
+	if err := parser.parseExcludes(); err != nil {
+		return diagram, err
+	}
+	return diagram, parser.schedule()
+}
+
+// addTask parses "name : [tags,] [id,] [start,] end" where end is a date,
+// a duration or "until id", and start is a date or "after id...".
+func (p *ganttParser) addTask(name, metadata, section string, line int) error {
+	task := GanttTask{Name: strings.TrimSpace(name), Section: section}
+
+	var fields []string
+	for _, field := range strings.Split(metadata, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	for len(fields) > 0 && ganttTags[fields[0]] {
+		switch fields[0] {
+		case "done":
+			task.Done = true
+		case "active":
+			task.Active = true
+		case "crit":
+			task.Critical = true
+		case "milestone":
+			task.Milestone = true
+		}
+		fields = fields[1:]
+	}
+
+	spec := ganttTaskSpec{line: line}
+	switch len(fields) {
+	case 1:
+		spec.end = fields[0]
+	case 2:
+		spec.start, spec.end = fields[0], fields[1]
+	case 3:
+		task.ID, spec.start, spec.end = fields[0], fields[1], fields[2]
+	default:
+		return fmt.Errorf("invalid task metadata %q", metadata)
+	}
+
+	if task.ID != "" {
+		if _, exists := p.taskIndex[task.ID]; exists {
+			return fmt.Errorf("duplicate task id %s", task.ID)
+		}
+		p.taskIndex[task.ID] = len(p.diagram.Tasks)
+	}
+	if ids, ok := strings.CutPrefix(spec.start, "after "); ok {
+		task.After = strings.Fields(ids)
+	}
+	p.diagram.Tasks = append(p.diagram.Tasks, task)
+	p.specs = append(p.specs, spec)
+	return nil
+}
+
+func (p *ganttParser) parseExcludes() error {
+	for _, exclude := range p.excludes {
+		name := strings.ToLower(exclude)
+		if name == "weekends" {
+			p.diagram.ExcludeWeekends = true
+			continue
+		}
+		if weekday, ok := parseWeekday(name); ok {
+			p.diagram.ExcludedWeekdays = append(p.diagram.ExcludedWeekdays, weekday)
+			continue
+		}
+		date, err := parseGanttDate(exclude, p.diagram.DateFormat)
+		if err != nil {
+			return fmt.Errorf("excludes: %w", err)
+		}
+		p.diagram.ExcludedDates = append(p.diagram.ExcludedDates, date)
+	}
+	return nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if strings.ToLower(weekday.String()) == name {
+			return weekday, true
+		}
+	}
+	return 0, false
+}
+
+// schedule resolves task dates repeatedly until no more tasks can be
+// resolved; whatever is left waits on a dependency cycle.
+func (p *ganttParser) schedule() error {
+	resolved := make([]bool, len(p.specs))
+	for progress := true; progress; {
+		progress = false
+		for i := range p.specs {
+			if resolved[i] {
+				continue
+			}
+			ok, err := p.resolve(i, resolved)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", p.specs[i].line, err)
+			}
+			if ok {
+				resolved[i] = true
+				progress = true
+			}
+		}
+	}
+
+	for i, ok := range resolved {
+		if !ok {
+			return fmt.Errorf("line %d: cannot schedule %s: circular dependency", p.specs[i].line, p.diagram.Tasks[i].Name)
+		}
+	}
+	return nil
+}
+
+// resolve computes the dates of task i, returning false while a task it
+// depends on is still unresolved.
+func (p *ganttParser) resolve(i int, resolved []bool) (bool, error) {
+	task := &p.diagram.Tasks[i]
+	spec := p.specs[i]
+
+	switch {
+	case spec.start == "":
+		if i == 0 {
+			return false, fmt.Errorf("task %s needs a start date", task.Name)
+		}
+		if !resolved[i-1] {
+			return false, nil
+		}
+		task.Start = p.diagram.Tasks[i-1].End
+	case len(task.After) > 0:
+		for j, id := range task.After {
+			index, ok := p.taskIndex[id]
+			if !ok {
+				return false, fmt.Errorf("unknown task %s", id)
+			}
+			if !resolved[index] {
+				return false, nil
+			}
+			if end := p.diagram.Tasks[index].End; j == 0 || end.After(task.Start) {
+				task.Start = end
+			}
+		}
+	default:
+		start, err := parseGanttDate(spec.start, p.diagram.DateFormat)
+		if err != nil {
+			return false, err
+		}
+		task.Start = start
+	}
+
+	if ids, ok := strings.CutPrefix(spec.end, "until "); ok {
+		for j, id := range strings.Fields(ids) {
+			index, ok := p.taskIndex[id]
+			if !ok {
+				return false, fmt.Errorf("unknown task %s", id)
+			}
+			if !resolved[index] {
+				return false, nil
+			}
+			if start := p.diagram.Tasks[index].Start; j == 0 || start.Before(task.End) {
+				task.End = start
+			}
+		}
+		return true, nil
+	}
+
+	if duration, ok := parseGanttDuration(spec.end); ok {
+		task.End = task.Start.Add(duration)
+		if duration > 0 {
+			end, err := p.skipExcluded(task.Start, task.End)
+			if err != nil {
+				return false, err
+			}
+			task.End = end
+		}
+		return true, nil
+	}
+
+	end, err := parseGanttDate(spec.end, p.diagram.DateFormat)
+	if err != nil {
+		return false, err
+	}
+	task.End = end
+	return true, nil
+}
+
+// skipExcluded pushes end back by one day for every excluded day the task
+// would otherwise cover, as Mermaid does for duration-based tasks. A run of
+// excluded days longer than a week plus every excluded date can only mean
+// that all weekdays are excluded, which would never end.
+func (p *ganttParser) skipExcluded(start, end time.Time) (time.Time, error) {
+	limit := 7 + len(p.diagram.ExcludedDates)
+	consecutive := 0
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if !p.diagram.IsExcluded(day) {
+			consecutive = 0
+			continue
+		}
+		if consecutive++; consecutive > limit {
+			return end, fmt.Errorf("every day is excluded")
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+	return end, nil
+}
+
+func parseGanttDuration(text string) (time.Duration, bool) {
+	match := ganttDurationRegex.FindStringSubmatch(text)
+	if match == nil {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	units := map[string]time.Duration{
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  24 * time.Hour,
+		"w":  7 * 24 * time.Hour,
+	}
+	return time.Duration(value * float64(units[match[2]])), true
+}
+
+// parseGanttDate parses text with a dayjs format such as "YYYY-MM-DD HH:mm".
+// Dates are interpreted in UTC so that schedules do not depend on the host.
+func parseGanttDate(text, format string) (time.Time, error) {
+	switch format {
+	case "X", "x":
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q for format %s", text, format)
+		}
+		if format == "x" {
+			return time.UnixMilli(value).UTC(), nil
+		}
+		return time.Unix(value, 0).UTC(), nil
+	}
+
+	date, err := time.ParseInLocation(dayjsLayout(format), text, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q for format %s", text, format)
+	}
+	return date, nil
+}
+
+// dayjsLayout converts a dayjs format into a Go time layout. Bracketed text
+// is kept literally.
+func dayjsLayout(format string) string {
+	return dayjsTokenRegex.ReplaceAllStringFunc(format, func(token string) string {
+		if strings.HasPrefix(token, "[") {
+			return token[1 : len(token)-1]
+		}
+		return dayjsLayouts[token]
+	})
+}
@@ -0,0 +1,127 @@
+package mermaid
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGanttDiagram(t *testing.T) {
+	input := `gantt
+    title Release plan
+    dateFormat YYYY-MM-DD
+    axisFormat %m/%d
+    excludes weekends, 2024-01-17
+    todayMarker stroke-width:5px,stroke:#0f0
+    section Design
+    Spec           :done, spec, 2024-01-01, 3d
+    Review         :crit, active, review, after spec, 2d
+    section Build
+    Implement      :impl, after review, 1w
+    Polish         :2d
+    Ship           :milestone, ship, after impl, 0d`
+
+	parsed, err := ParseDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	diagram, ok := parsed.(*GanttDiagram)
+	if !ok {
+		t.Fatalf("Expected a gantt diagram, got %T", parsed)
+	}
+
+	if diagram.Title != "Release plan" || diagram.AxisFormat != "%m/%d" || diagram.TodayMarker != "stroke-width:5px,stroke:#0f0" {
+		t.Errorf("Unexpected settings: %+v", diagram)
+	}
+	if !diagram.ExcludeWeekends || len(diagram.ExcludedDates) != 1 {
+		t.Errorf("Expected weekends and one date excluded, got %v %v", diagram.ExcludeWeekends, diagram.ExcludedDates)
+	}
+	if len(diagram.Sections) != 2 || diagram.Sections[1] != "Build" {
+		t.Errorf("Unexpected sections: %v", diagram.Sections)
+	}
+
+	date := func(day int) time.Time {
+		return time.Date(2024, time.January, day, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+	}{
+		{"Spec", date(1), date(4)},
+		{"Review", date(4), date(8)},     // skips the weekend
+		{"Implement", date(8), date(18)}, // skips the weekend and the 17th
+		{"Polish", date(18), date(22)},   // starts when Implement ends
+		{"Ship", date(18), date(18)},     // after impl, not after Polish
+	}
+	if len(diagram.Tasks) != len(tests) {
+		t.Fatalf("Expected %d tasks, got %d", len(tests), len(diagram.Tasks))
+	}
+	for i, tt := range tests {
+		task := diagram.Tasks[i]
+		if task.Name != tt.name || !task.Start.Equal(tt.start) || !task.End.Equal(tt.end) {
+			t.Errorf("Task %d: expected %s %s..%s, got %s %s..%s", i, tt.name, tt.start.Format(time.DateOnly), tt.end.Format(time.DateOnly),
+				task.Name, task.Start.Format(time.DateOnly), task.End.Format(time.DateOnly))
+		}
+	}
+
+	review := diagram.Tasks[1]
+	if review.ID != "review" || !review.Critical || !review.Active || review.Done || review.Section != "Design" {
+		t.Errorf("Unexpected review task: %+v", review)
+	}
+	if len(review.After) != 1 || review.After[0] != "spec" {
+		t.Errorf("Expected review after spec, got %v", review.After)
+	}
+	if !diagram.Tasks[0].Done || !diagram.Tasks[4].Milestone || diagram.Tasks[3].Section != "Build" {
+		t.Errorf("Unexpected tags or sections: %+v", diagram.Tasks)
+	}
+}
+
+func TestParseGanttScheduling(t *testing.T) {
+	input := `gantt
+    dateFormat DD.MM.YYYY HH:mm
+    Deploy    :deploy, after build test, 2h
+    Build     :build, 01.03.2024 09:00, 3h
+    Test      :test, 01.03.2024 10:00, 4h
+    Freeze    :freeze, 01.03.2024 08:00, until deploy`
+
+	diagram, err := ParseGanttDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	at := func(hour int) time.Time {
+		return time.Date(2024, time.March, 1, hour, 0, 0, 0, time.UTC)
+	}
+	deploy, freeze := diagram.Tasks[0], diagram.Tasks[3]
+	if !deploy.Start.Equal(at(14)) || !deploy.End.Equal(at(16)) {
+		t.Errorf("Expected deploy after the later dependency, got %v..%v", deploy.Start, deploy.End)
+	}
+	if !freeze.Start.Equal(at(8)) || !freeze.End.Equal(at(14)) {
+		t.Errorf("Expected freeze until deploy, got %v..%v", freeze.Start, freeze.End)
+	}
+}
+
+func TestParseGanttErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"missing start", "gantt\n    A :3d", "line 2: task A needs a start date"},
+		{"unknown dependency", "gantt\n    A :a, after b, 3d", "line 2: unknown task b"},
+		{"cycle", "gantt\n    A :a, after b, 1d\n    B :b, after a, 1d", "line 2: cannot schedule A: circular dependency"},
+		{"invalid date", "gantt\n    A :a, 2024/01/01, 1d", `line 2: invalid date "2024/01/01" for format YYYY-MM-DD`},
+		{"duplicate id", "gantt\n    A :a, 2024-01-01, 1d\n    B :a, 2024-01-01, 1d", "line 3: duplicate task id a"},
+		{"every day excluded", "gantt\n    excludes weekends, monday, tuesday, wednesday, thursday, friday\n    A :a, 2024-01-01, 1d", "line 3: every day is excluded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGanttDiagram(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
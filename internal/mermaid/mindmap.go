@@ -0,0 +1,177 @@
+package mermaid
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type MindmapDiagram struct {
+	Nodes []MindmapNode // in source order, the root first
+}
+
+func (md *MindmapDiagram) GetType() DiagramType {
+	return MindmapDiagramType
+}
+
+type MindmapNode struct {
+	ID      string
+	Label   string
+	Shape   MindmapShape
+	Icon    string   // e.g. "fa fa-book" from ::icon(fa fa-book)
+	Classes []string // from :::class1 class2
+	Parent  int      // index into Nodes, -1 for the root
+	Level   int      // 0 for the root
+}
+
+type MindmapShape int
+
+const (
+	MindmapDefault MindmapShape = iota // text
+	MindmapSquare                      // id[text]
+	MindmapRounded                     // id(text)
+	MindmapCircle                      // id((text))
+	MindmapBang                        // id))text((
+	MindmapCloud                       // id)text(
+	MindmapHexagon                     // id{{text}}
+)
+
+var (
+	mindmapHeaderRegex = regexp.MustCompile(`^mindmap\b`)
+	mindmapIconRegex   = regexp.MustCompile(`^::icon\((.*)\)$`)
+	mindmapClassRegex  = regexp.MustCompile(`\s*:::\s*(.+)$`)
+)
+
+// mindmapShapeDelimiters lists the longest openers first so that "((" is
+// tried before "(" when two openers start at the same position.
+var mindmapShapeDelimiters = []struct {
+	open  string
+	close string
+	shape MindmapShape
+}{
+	{"((", "))", MindmapCircle},
+	{"))", "((", MindmapBang},
+	{"{{", "}}", MindmapHexagon},
+	{"[", "]", MindmapSquare},
+	{"(", ")", MindmapRounded},
+	{")", "(", MindmapCloud},
+}
+
+type mindmapIndent struct {
+	width int
+	node  int
+}
+
+// ParseMindmapDiagram builds the hierarchy from indentation: a node's parent
+// is the closest preceding node that is indented less.
+func ParseMindmapDiagram(input string) (*MindmapDiagram, error) {
+	diagram := &MindmapDiagram{
+		Nodes: make([]MindmapNode, 0),
+	}
+	var stack []mindmapIndent
+
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+
+		if line == "" || strings.HasPrefix(line, "%%") || mindmapHeaderRegex.MatchString(line) {
+			continue
+		}
+
+		// Decorations apply to the node declared just before them
+		if icon := mindmapIconRegex.FindStringSubmatch(line); icon != nil {
+			if len(diagram.Nodes) == 0 {
+				return diagram, fmt.Errorf("line %d: icon without a node", lineNumber)
+			}
+			diagram.Nodes[len(diagram.Nodes)-1].Icon = strings.TrimSpace(icon[1])
+			continue
+		}
+		if strings.HasPrefix(line, ":::") {
+			if len(diagram.Nodes) == 0 {
+				return diagram, fmt.Errorf("line %d: class without a node", lineNumber)
+			}
+			last := &diagram.Nodes[len(diagram.Nodes)-1]
+			last.Classes = append(last.Classes, strings.Fields(strings.TrimPrefix(line, ":::"))...)
+			continue
+		}
+
+		node := parseMindmapNode(line)
+		width := mindmapIndentWidth(raw)
+		for len(stack) > 0 && stack[len(stack)-1].width >= width {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			if len(diagram.Nodes) > 0 {
+				return diagram, fmt.Errorf("line %d: mindmap can only have one root", lineNumber)
+			}
+			node.Parent = -1
+		} else {
+			parent := stack[len(stack)-1].node
+			node.Parent = parent
+			node.Level = diagram.Nodes[parent].Level + 1
+		}
+
+		stack = append(stack, mindmapIndent{width: width, node: len(diagram.Nodes)})
+		diagram.Nodes = append(diagram.Nodes, node)
+	}
+
+	return diagram, scanner.Err()
+}
+
+// mindmapIndentWidth counts leading whitespace, with tabs as four columns.
+func mindmapIndentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// parseMindmapNode splits "id(text)" style declarations. The opener that
+// appears first decides the shape; plain text is both id and label.
+func parseMindmapNode(text string) MindmapNode {
+	var node MindmapNode
+	if class := mindmapClassRegex.FindStringSubmatchIndex(text); class != nil {
+		node.Classes = strings.Fields(text[class[2]:class[3]])
+		text = text[:class[0]]
+	}
+
+	best := -1
+	for _, delimiter := range mindmapShapeDelimiters {
+		index := strings.Index(text, delimiter.open)
+		if index < 0 || (best >= 0 && index >= best) {
+			continue
+		}
+		if !strings.HasSuffix(text, delimiter.close) || len(text) < index+len(delimiter.open)+len(delimiter.close) {
+			continue
+		}
+		best = index
+		node.ID = strings.TrimSpace(text[:index])
+		node.Label = text[index+len(delimiter.open) : len(text)-len(delimiter.close)]
+		node.Shape = delimiter.shape
+	}
+
+	if best < 0 {
+		node.ID, node.Label = text, text
+		return node
+	}
+	node.Label = strings.TrimSpace(node.Label)
+	if len(node.Label) >= 2 && strings.HasPrefix(node.Label, `"`) && strings.HasSuffix(node.Label, `"`) {
+		node.Label = node.Label[1 : len(node.Label)-1]
+	}
+	if node.ID == "" {
+		node.ID = node.Label
+	}
+	return node
+}
@@ -0,0 +1,88 @@
+package mermaid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMindmapDiagram(t *testing.T) {
+	input := `mindmap
+  root((Plans))
+    Origins
+      ::icon(fa fa-book)
+      hist[History]
+        deep))Deep dive((
+    Tools:::urgent large
+      c)Cloud(
+      h{{Hex}}
+      r("Rounded (soft)")
+    %% comment
+	Research
+      :::quiet`
+
+	parsed, err := ParseDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	diagram, ok := parsed.(*MindmapDiagram)
+	if !ok {
+		t.Fatalf("Expected a mindmap diagram, got %T", parsed)
+	}
+
+	tests := []struct {
+		id     string
+		label  string
+		shape  MindmapShape
+		parent int
+		level  int
+	}{
+		{"root", "Plans", MindmapCircle, -1, 0},
+		{"Origins", "Origins", MindmapDefault, 0, 1},
+		{"hist", "History", MindmapSquare, 1, 2},
+		{"deep", "Deep dive", MindmapBang, 2, 3},
+		{"Tools", "Tools", MindmapDefault, 0, 1},
+		{"c", "Cloud", MindmapCloud, 4, 2},
+		{"h", "Hex", MindmapHexagon, 4, 2},
+		{"r", "Rounded (soft)", MindmapRounded, 4, 2},
+		{"Research", "Research", MindmapDefault, 0, 1}, // a tab counts as four columns
+	}
+	if len(diagram.Nodes) != len(tests) {
+		t.Fatalf("Expected %d nodes, got %d", len(tests), len(diagram.Nodes))
+	}
+	for i, tt := range tests {
+		node := diagram.Nodes[i]
+		if node.ID != tt.id || node.Label != tt.label || node.Shape != tt.shape || node.Parent != tt.parent || node.Level != tt.level {
+			t.Errorf("Node %d: expected %+v, got %+v", i, tt, node)
+		}
+	}
+
+	if diagram.Nodes[1].Icon != "fa fa-book" {
+		t.Errorf("Expected icon on Origins, got %q", diagram.Nodes[1].Icon)
+	}
+	if classes := diagram.Nodes[4].Classes; len(classes) != 2 || classes[0] != "urgent" || classes[1] != "large" {
+		t.Errorf("Expected inline classes on Tools, got %v", classes)
+	}
+	if classes := diagram.Nodes[8].Classes; len(classes) != 1 || classes[0] != "quiet" {
+		t.Errorf("Expected class line on Research, got %v", classes)
+	}
+}
+
+func TestParseMindmapErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"second root", "mindmap\n  A\n    B\n  C", "line 4: mindmap can only have one root"},
+		{"icon first", "mindmap\n  ::icon(fa fa-book)", "line 2: icon without a node"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMindmapDiagram(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package mermaid
 
 import (
 	"bufio"
+	"fmt"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -11,6 +14,12 @@ type DiagramType int
 const (
 	SequenceDiagramType DiagramType = iota
 	ERDiagramType
+	FlowchartDiagramType
+	ClassDiagramType
+	StateDiagramType
+	GanttDiagramType
+	PieDiagramType
+	MindmapDiagramType
 )
 
 type Diagram interface {
@@ -20,17 +29,52 @@ type Diagram interface {
 type SequenceDiagram struct {
 	Participants []Participant
 	Messages     []Message
+	Notes        []Note
+	Events       []SequenceEvent // messages and notes in source order
+	Activations  []Activation
+	Blocks       []Block
+	Boxes        []Box
+}
+
+// Box groups participants declared between "box [color] [title]" and "end".
+type Box struct {
+	Title        string
+	Color        Color
+	Participants []string
 }
 
 func (sd *SequenceDiagram) GetType() DiagramType {
 	return SequenceDiagramType
 }
 
+// Participant is a lifeline of the diagram. Participants declared with
+// "create" start at the timeline row CreatedAt instead of the top, and
+// destroyed ones end at DestroyedAt (rows index into Events).
 type Participant struct {
-	Name  string
-	Alias string
+	Name        string
+	Alias       string
+	Kind        ParticipantKind
+	Created     bool
+	CreatedAt   int
+	Destroyed   bool
+	DestroyedAt int
 }
 
+// ParticipantKind selects the symbol drawn for a participant: "actor" lines
+// and the "type" of a participant@{ ... } annotation.
+type ParticipantKind int
+
+const (
+	ParticipantBox ParticipantKind = iota
+	ActorParticipant
+	BoundaryParticipant
+	ControlParticipant
+	EntityParticipant
+	DatabaseParticipant
+	CollectionsParticipant
+	QueueParticipant
+)
+
 type Message struct {
 	From    string
 	To      string
@@ -38,16 +82,96 @@ type Message struct {
 	Type    MessageType
 	Activate bool
 	Deactivate bool
+	Numbered bool // set while autonumber is on
+	Number   int
 }
 
 type MessageType int
 
 const (
-	SolidArrow MessageType = iota
-	DashedArrow
-	SolidArrowWithX
-	DashedArrowWithX
-	Note
+	SolidLine           MessageType = iota // ->
+	DashedLine                             // -->
+	SolidArrow                             // ->>
+	DashedArrow                            // -->>
+	SolidCross                             // -x
+	DashedCross                            // --x
+	SolidAsync                             // -)
+	DashedAsync                            // --)
+	SolidBidirectional                     // <<->>
+	DashedBidirectional                    // <<-->>
+)
+
+// Activation is a bar on a participant's lifeline spanning the timeline rows
+// Start to End (indices into Events). Level counts the bars already open on
+// the participant when it starts, so nested bars can be stacked.
+type Activation struct {
+	Participant string
+	Start       int
+	End         int
+	Level       int
+}
+
+// Note is a "Note left of A", "Note right of A" or "Note over A,B" element.
+type Note struct {
+	Placement    NotePlacement
+	Participants []string
+	Text         string
+}
+
+type NotePlacement int
+
+const (
+	NoteLeftOf NotePlacement = iota
+	NoteRightOf
+	NoteOver
+)
+
+// SequenceEvent is one row of the sequence diagram timeline. Index points
+// into Messages or Notes depending on Kind.
+type SequenceEvent struct {
+	Kind  SequenceEventKind
+	Index int
+}
+
+type SequenceEventKind int
+
+const (
+	MessageEvent SequenceEventKind = iota
+	NoteEvent
+	BlockStartEvent   // Index points into Blocks
+	BlockSectionEvent // an else/and/option line of the block at Index
+	BlockEndEvent
+)
+
+// Block is a combined fragment such as "loop" or "alt". Sections holds the
+// first section and those opened by else/and/option, each starting at an
+// event row; Start and End are the rows of the opening and "end" lines.
+// Parent is the index of the enclosing block, or -1. Color is the fill of
+// a "rect" highlight region.
+type Block struct {
+	Kind     BlockKind
+	Sections []BlockSection
+	Parent   int
+	Start    int
+	End      int
+	Color    Color
+}
+
+type BlockSection struct {
+	Label string
+	Start int
+}
+
+type BlockKind int
+
+const (
+	LoopBlock BlockKind = iota
+	AltBlock
+	OptBlock
+	ParBlock
+	CriticalBlock
+	BreakBlock
+	RectBlock
 )
 
 type ERDiagram struct {
@@ -59,11 +183,17 @@ func (erd *ERDiagram) GetType() DiagramType {
 	return ERDiagramType
 }
 
+// Entity is a table of the diagram; Alias is its display name, empty to show
+// Name.
 type Entity struct {
 	Name       string
+	Alias      string
 	Attributes []Attribute
 }
 
+// Attribute is a row of an entity. Types may carry a length ("varchar(255)"),
+// an array suffix ("string[]") or a generic parameter ("list~int~"); Comment
+// is the optional trailing quoted string.
 type Attribute struct {
 	Name       string
 	Type       string
@@ -71,14 +201,22 @@ type Attribute struct {
 	IsFK       bool
 	IsUnique   bool
 	IsNotNull  bool
+	Comment    string
 }
 
+// Relationship connects two entities. Type, FromCardinality and
+// ToCardinality summarise the ends as one ("1") or many ("M"); FromEnd and
+// ToEnd keep the full crow's-foot cardinality, and Identification tells
+// identifying ("--") from non-identifying ("..") relationships.
 type Relationship struct {
 	From         string
 	To           string
 	Type         RelationshipType
 	FromCardinality string
 	ToCardinality   string
+	FromEnd         Cardinality
+	ToEnd           Cardinality
+	Identification  Identification
 	Label        string
 }
 
@@ -89,10 +227,29 @@ const (
 	OneToMany
 	ManyToOne
 	ManyToMany
-	Identifying
+)
+
+// Identification tells identifying ("--") from non-identifying ("..")
+// relationships.
+type Identification int
+
+const (
+	Identifying Identification = iota
 	NonIdentifying
 )
 
+// Cardinality is one crow's-foot end of a relationship. The zero value
+// means the end is unknown and follows the relationship Type.
+type Cardinality int
+
+const (
+	UnspecifiedCardinality Cardinality = iota
+	ZeroOrOne                          // |o or o|
+	ExactlyOne                         // ||
+	ZeroOrMore                         // }o or o{
+	OneOrMore                          // }| or |{
+)
+
 func ParseDiagram(input string) (Diagram, error) {
 	diagramType := DetectDiagramType(input)
 	
@@ -101,6 +258,18 @@ func ParseDiagram(input string) (Diagram, error) {
 		return ParseSequenceDiagram(input)
 	case ERDiagramType:
 		return ParseERDiagram(input)
+	case FlowchartDiagramType:
+		return ParseFlowchartDiagram(input)
+	case ClassDiagramType:
+		return ParseClassDiagram(input)
+	case StateDiagramType:
+		return ParseStateDiagram(input)
+	case GanttDiagramType:
+		return ParseGanttDiagram(input)
+	case PieDiagramType:
+		return ParsePieDiagram(input)
+	case MindmapDiagramType:
+		return ParseMindmapDiagram(input)
 	default:
 		return ParseSequenceDiagram(input) // Default to sequence diagram
 	}
@@ -116,6 +285,24 @@ func DetectDiagramType(input string) DiagramType {
 		if strings.HasPrefix(line, "sequenceDiagram") {
 			return SequenceDiagramType
 		}
+		if flowchartHeaderRegex.MatchString(line) {
+			return FlowchartDiagramType
+		}
+		if classDiagramHeaderRegex.MatchString(line) {
+			return ClassDiagramType
+		}
+		if stateDiagramHeaderRegex.MatchString(line) {
+			return StateDiagramType
+		}
+		if ganttHeaderRegex.MatchString(line) {
+			return GanttDiagramType
+		}
+		if pieHeaderRegex.MatchString(line) {
+			return PieDiagramType
+		}
+		if mindmapHeaderRegex.MatchString(line) {
+			return MindmapDiagramType
+		}
 	}
 	return SequenceDiagramType // Default
 }
@@ -127,9 +314,8 @@ func ParseERDiagram(input string) (*ERDiagram, error) {
 	}
 	
 	scanner := bufio.NewScanner(strings.NewReader(input))
-	entityMap := make(map[string]*Entity)
-	var currentEntity *Entity
-	inEntity := false
+	entityIndex := make(map[string]int)
+	currentEntity := -1
 	
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -138,46 +324,40 @@ func ParseERDiagram(input string) (*ERDiagram, error) {
 			continue
 		}
 		
-		if line == "}" && inEntity {
-			if currentEntity != nil {
-				diagram.Entities = append(diagram.Entities, *currentEntity)
-				entityMap[currentEntity.Name] = currentEntity
-			}
-			inEntity = false
-			currentEntity = nil
+		if line == "}" && currentEntity >= 0 {
+			currentEntity = -1
 			continue
 		}
 		
-		if entity := parseEntityStart(line); entity != nil {
-			currentEntity = entity
-			inEntity = true
-			
-			// Check if the entity definition ends on the same line (e.g., "USER {}")
-			if strings.HasSuffix(line, "{}") {
-				diagram.Entities = append(diagram.Entities, *currentEntity)
-				entityMap[currentEntity.Name] = currentEntity
-				inEntity = false
-				currentEntity = nil
+		if currentEntity >= 0 {
+			if attr := parseAttribute(line); attr != nil {
+				entity := &diagram.Entities[currentEntity]
+				entity.Attributes = append(entity.Attributes, *attr)
 			}
 			continue
 		}
 		
-		if inEntity && currentEntity != nil {
-			if attr := parseAttribute(line); attr != nil {
-				currentEntity.Attributes = append(currentEntity.Attributes, *attr)
+		// Blocks of the same entity add to its attributes; the entity
+		// definition may end on the same line (e.g., "USER {}")
+		if entity, opensBlock := parseEntityDeclaration(line); entity != nil {
+			index := ensureEntity(diagram, entityIndex, entity.Name)
+			if entity.Alias != "" {
+				diagram.Entities[index].Alias = entity.Alias
+			}
+			if opensBlock && !strings.HasSuffix(line, "}") {
+				currentEntity = index
 			}
 			continue
 		}
 		
+		// Entities referenced only by relationships are created on the fly
 		if rel := parseRelationship(line); rel != nil {
+			ensureEntity(diagram, entityIndex, rel.From)
+			ensureEntity(diagram, entityIndex, rel.To)
 			diagram.Relationships = append(diagram.Relationships, *rel)
 		}
 	}
 	
-	if inEntity && currentEntity != nil {
-		diagram.Entities = append(diagram.Entities, *currentEntity)
-	}
-	
 	return diagram, scanner.Err()
 }
 
@@ -185,96 +365,228 @@ func shouldSkipLine(line string) bool {
 	return line == "" || strings.HasPrefix(line, "%%") || strings.HasPrefix(line, "erDiagram")
 }
 
-func parseEntityStart(line string) *Entity {
-	entityRegex := regexp.MustCompile(`^\s*(\w+)\s*\{`)
-	if matches := entityRegex.FindStringSubmatch(line); matches != nil {
-		return &Entity{
-			Name:       matches[1],
-			Attributes: make([]Attribute, 0),
-		}
+// ensureEntity returns the index of the named entity, appending it first if
+// it is not known yet.
+func ensureEntity(diagram *ERDiagram, entityIndex map[string]int, name string) int {
+	if index, exists := entityIndex[name]; exists {
+		return index
 	}
-	return nil
+	entityIndex[name] = len(diagram.Entities)
+	diagram.Entities = append(diagram.Entities, Entity{
+		Name:       name,
+		Attributes: make([]Attribute, 0),
+	})
+	return entityIndex[name]
+}
+
+// Entity names are words that may contain hyphens, or quoted strings.
+const erEntityPattern = `("[^"]*"|[A-Za-z_][\w-]*)`
+
+var entityDeclarationRegex = regexp.MustCompile(`^` + erEntityPattern + `\s*(?:\[\s*(?:"([^"]*)"|([^\]"]*?))\s*\])?\s*(\{)?\s*\}?$`)
+
+// parseEntityDeclaration parses "NAME", "NAME[alias]" or "NAME["alias"]",
+// optionally opening an attribute block with "{".
+func parseEntityDeclaration(line string) (*Entity, bool) {
+	matches := entityDeclarationRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil, false
+	}
+	entity := &Entity{
+		Name:       unquoteEntityName(matches[1]),
+		Alias:      matches[2] + strings.TrimSpace(matches[3]),
+		Attributes: make([]Attribute, 0),
+	}
+	return entity, matches[4] != ""
+}
+
+func unquoteEntityName(name string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(name, `"`), `"`)
 }
 
+// Attribute keys may be listed with commas ("PK, FK") or spaces.
+var attributeRegex = regexp.MustCompile(`^\s*([A-Za-z_][\w\-()\[\],~]*)\s+([A-Za-z_*][\w\-()\[\]]*)((?:(?:\s*,\s*|\s+)(?:PK|FK|UK|NOT NULL))*)(?:\s*"([^"]*)")?`)
+
 func parseAttribute(line string) *Attribute {
-	attributeRegex := regexp.MustCompile(`^\s*(\w+)\s+(\w+)(?:\s+(PK|FK|UK|NOT NULL))*`)
 	if matches := attributeRegex.FindStringSubmatch(line); matches != nil {
 		attr := &Attribute{
-			Name: matches[2],
-			Type: matches[1],
+			Name:    matches[2],
+			Type:    matches[1],
+			Comment: matches[4],
 		}
 		
-		if len(matches) > 3 {
-			constraints := strings.ToUpper(matches[3])
-			attr.IsPK = strings.Contains(constraints, "PK")
-			attr.IsFK = strings.Contains(constraints, "FK")
-			attr.IsUnique = strings.Contains(constraints, "UK")
-			attr.IsNotNull = strings.Contains(constraints, "NOT NULL")
-		}
+		constraints := matches[3]
+		attr.IsPK = strings.Contains(constraints, "PK")
+		attr.IsFK = strings.Contains(constraints, "FK")
+		attr.IsUnique = strings.Contains(constraints, "UK")
+		attr.IsNotNull = strings.Contains(constraints, "NOT NULL")
 		
 		return attr
 	}
 	return nil
 }
 
+// Relationship lines are "<entity> <cardinality><line><cardinality> <entity>
+// : <label>". Cardinalities are crow's-foot symbols or their word aliases,
+// optionally quoted; the line is "--"/"to" (identifying) or
+// ".."/"optionally to" (non-identifying).
+var (
+	erCardinalityPattern = `"?(\|o|\|\||\}o|\}\||o\||o\{|\|\{|one or zero|zero or one|one or more|one or many|many\(1\)|1\+|zero or more|zero or many|many\(0\)|0\+|only one|1)"?`
+	relationshipRegex    = regexp.MustCompile(`^` + erEntityPattern + `\s*` + erCardinalityPattern + `\s*(--|\.\.|optionally to|to)\s*` + erCardinalityPattern + `\s*` + erEntityPattern + `$`)
+)
+
+var erCardinalities = map[string]Cardinality{
+	"|o":           ZeroOrOne,
+	"o|":           ZeroOrOne,
+	"one or zero":  ZeroOrOne,
+	"zero or one":  ZeroOrOne,
+	"||":           ExactlyOne,
+	"only one":     ExactlyOne,
+	"1":            ExactlyOne,
+	"}o":           ZeroOrMore,
+	"o{":           ZeroOrMore,
+	"zero or more": ZeroOrMore,
+	"zero or many": ZeroOrMore,
+	"many(0)":      ZeroOrMore,
+	"0+":           ZeroOrMore,
+	"}|":           OneOrMore,
+	"|{":           OneOrMore,
+	"one or more":  OneOrMore,
+	"one or many":  OneOrMore,
+	"many(1)":      OneOrMore,
+	"1+":           OneOrMore,
+}
+
 func parseRelationship(line string) *Relationship {
-	if !strings.Contains(line, "--") || !strings.Contains(line, ":") {
+	left, label, ok := splitRelationshipLabel(line)
+	if !ok {
 		return nil
 	}
 	
-	parts := strings.Split(line, ":")
-	if len(parts) < 2 {
+	// Word aliases may be spread over several spaces
+	matches := relationshipRegex.FindStringSubmatch(strings.Join(strings.Fields(left), " "))
+	if matches == nil {
 		return nil
 	}
 	
-	leftPart := strings.TrimSpace(parts[0])
-	label := strings.TrimSpace(parts[1])
-	
-	words := strings.Fields(leftPart)
-	if len(words) < 3 {
-		return nil
+	fromEnd := erCardinalities[matches[2]]
+	toEnd := erCardinalities[matches[4]]
+	identification := Identifying
+	if matches[3] == ".." || matches[3] == "optionally to" {
+		identification = NonIdentifying
 	}
-	
-	from := words[0]
-	to := words[len(words)-1]
-	relationSymbol := strings.Join(words[1:len(words)-1], " ")
-	
-	relType, fromCard, toCard := parseRelationshipSymbol(relationSymbol)
+	relType, fromCard, toCard := relationshipType(fromEnd, toEnd)
 	
 	return &Relationship{
-		From:            from,
-		To:              to,
+		From:            unquoteEntityName(matches[1]),
+		To:              unquoteEntityName(matches[5]),
 		Type:            relType,
 		FromCardinality: fromCard,
 		ToCardinality:   toCard,
+		FromEnd:         fromEnd,
+		ToEnd:           toEnd,
+		Identification:  identification,
 		Label:           label,
 	}
 }
 
+// splitRelationshipLabel splits a relationship line at the first colon
+// outside quotes. A quoted label loses its quotes and may contain colons.
+func splitRelationshipLabel(line string) (string, string, bool) {
+	inQuotes := false
+	for i, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == ':' && !inQuotes:
+			label := strings.TrimSpace(line[i+1:])
+			if len(label) >= 2 && strings.HasPrefix(label, `"`) && strings.HasSuffix(label, `"`) {
+				label = label[1 : len(label)-1]
+			}
+			return strings.TrimSpace(line[:i]), label, true
+		}
+	}
+	return "", "", false
+}
+
+// relationshipType summarises two cardinalities as one ("1") or many ("M").
+func relationshipType(fromEnd, toEnd Cardinality) (RelationshipType, string, string) {
+	fromCard, toCard := "1", "1"
+	if fromEnd == ZeroOrMore || fromEnd == OneOrMore {
+		fromCard = "M"
+	}
+	if toEnd == ZeroOrMore || toEnd == OneOrMore {
+		toCard = "M"
+	}
+	
+	switch {
+	case fromCard == "M" && toCard == "M":
+		return ManyToMany, fromCard, toCard
+	case fromCard == "M":
+		return ManyToOne, fromCard, toCard
+	case toCard == "M":
+		return OneToMany, fromCard, toCard
+	default:
+		return OneToOne, fromCard, toCard
+	}
+}
+
 func shouldSkipSequenceLine(line string) bool {
 	return line == "" || strings.HasPrefix(line, "%%") || strings.HasPrefix(line, "sequenceDiagram")
 }
 
+var participantTypeRegex = regexp.MustCompile(`"type"\s*:\s*"(\w+)"`)
+
 func parseParticipant(line string) *Participant {
-	participantRegex := regexp.MustCompile(`^\s*participant\s+(\w+)(?:\s+as\s+(.+))?`)
+	participantRegex := regexp.MustCompile(`^\s*(participant|actor)\s+(\w+)(?:\s*@\{([^}]*)\})?(?:\s+as\s+(.+))?`)
 	if matches := participantRegex.FindStringSubmatch(line); matches != nil {
-		name := matches[1]
+		name := matches[2]
 		alias := name
-		if len(matches) > 2 && matches[2] != "" {
-			alias = matches[2]
+		if matches[4] != "" {
+			alias = strings.TrimSpace(matches[4])
+		}
+		
+		kind := ParticipantBox
+		if matches[1] == "actor" {
+			kind = ActorParticipant
+		}
+		if typeMatches := participantTypeRegex.FindStringSubmatch(matches[3]); typeMatches != nil {
+			kind = getParticipantKind(typeMatches[1], kind)
 		}
-		return &Participant{Name: name, Alias: alias}
+		return &Participant{Name: name, Alias: alias, Kind: kind}
 	}
 	return nil
 }
 
+func getParticipantKind(name string, fallback ParticipantKind) ParticipantKind {
+	switch strings.ToLower(name) {
+	case "participant":
+		return ParticipantBox
+	case "actor":
+		return ActorParticipant
+	case "boundary":
+		return BoundaryParticipant
+	case "control":
+		return ControlParticipant
+	case "entity":
+		return EntityParticipant
+	case "database":
+		return DatabaseParticipant
+	case "collections":
+		return CollectionsParticipant
+	case "queue":
+		return QueueParticipant
+	default:
+		return fallback
+	}
+}
+
 func parseMessage(line string) *Message {
-	messageRegex := regexp.MustCompile(`^\s*(\w+)\s*(->|-->|->>|-->>)\s*(\w+)\s*:\s*(.*)`)
+	messageRegex := regexp.MustCompile(`^\s*(\w+)\s*(<<-->>|<<->>|-->>|->>|-->|->|--x|-x|--\)|-\))\s*([+-]?)\s*(\w+)\s*:\s*(.*)`)
 	if matches := messageRegex.FindStringSubmatch(line); matches != nil {
 		from := matches[1]
 		arrow := matches[2]
-		to := matches[3]
-		text := matches[4]
+		to := matches[4]
+		text := matches[5]
 		
 		msgType := getMessageType(arrow)
 		
@@ -283,6 +595,8 @@ func parseMessage(line string) *Message {
 			To:   to,
 			Text: text,
 			Type: msgType,
+			Activate: matches[3] == "+",
+			Deactivate: matches[3] == "-",
 		}
 	}
 	return nil
@@ -291,18 +605,162 @@ func parseMessage(line string) *Message {
 func getMessageType(arrow string) MessageType {
 	switch arrow {
 	case "->":
-		return SolidArrow
+		return SolidLine
 	case "-->":
-		return DashedArrow
+		return DashedLine
 	case "->>":
-		return SolidArrowWithX
+		return SolidArrow
 	case "-->>":
-		return DashedArrowWithX
+		return DashedArrow
+	case "-x":
+		return SolidCross
+	case "--x":
+		return DashedCross
+	case "-)":
+		return SolidAsync
+	case "--)":
+		return DashedAsync
+	case "<<->>":
+		return SolidBidirectional
+	case "<<-->>":
+		return DashedBidirectional
 	default:
-		return SolidArrow
+		return SolidLine
+	}
+}
+
+var boxRegex = regexp.MustCompile(`^box(?:\s+(.*))?$`)
+
+func parseBox(line string) *Box {
+	matches := boxRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil
+	}
+	box := &Box{Title: strings.TrimSpace(matches[1])}
+	if color, rest, ok := parseLeadingColor(box.Title); ok {
+		box.Color = color
+		box.Title = rest
+	}
+	return box
+}
+
+func inAnyBox(boxes []Box, participant string) bool {
+	for _, box := range boxes {
+		if slices.Contains(box.Participants, participant) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	createRegex  = regexp.MustCompile(`^create\s+((?:participant|actor)\s.*)$`)
+	destroyRegex = regexp.MustCompile(`^destroy\s+(\w+)$`)
+)
+
+// lifecycleTracker places "create" and "destroy" on the row of the next
+// message that involves the participant.
+type lifecycleTracker struct {
+	diagram    *SequenceDiagram
+	creating   map[string]bool
+	destroying map[string]bool
+}
+
+func (t *lifecycleTracker) apply(message Message, row int) {
+	for i := range t.diagram.Participants {
+		participant := &t.diagram.Participants[i]
+		if participant.Name != message.From && participant.Name != message.To {
+			continue
+		}
+		if t.creating[participant.Name] {
+			participant.CreatedAt = row
+			delete(t.creating, participant.Name)
+		}
+		if t.destroying[participant.Name] {
+			participant.Destroyed = true
+			participant.DestroyedAt = row
+			delete(t.destroying, participant.Name)
+		}
+	}
+}
+
+// finish places creations and destructions without a following message on
+// the last row.
+func (t *lifecycleTracker) finish(lastRow int) {
+	for i := range t.diagram.Participants {
+		participant := &t.diagram.Participants[i]
+		if t.creating[participant.Name] {
+			participant.CreatedAt = max(lastRow, 0)
+		}
+		if t.destroying[participant.Name] {
+			participant.Destroyed = true
+			participant.DestroyedAt = max(lastRow, participant.CreatedAt)
+		}
+	}
+}
+
+var autonumberRegex = regexp.MustCompile(`^autonumber(?:\s+(off|-?\d+)(?:\s+(-?\d+))?)?$`)
+
+// autonumber is the numbering state of "autonumber [start [step]]" and
+// "autonumber off" directives.
+type autonumber struct {
+	enabled bool
+	next    int
+	step    int
+}
+
+func (a *autonumber) configure(matches []string) {
+	if matches[1] == "off" {
+		a.enabled = false
+		return
+	}
+	a.enabled = true
+	a.next, a.step = 1, 1
+	if matches[1] != "" {
+		a.next, _ = strconv.Atoi(matches[1])
+	}
+	if matches[2] != "" {
+		a.step, _ = strconv.Atoi(matches[2])
 	}
 }
 
+func (a *autonumber) apply(message *Message) {
+	if !a.enabled {
+		return
+	}
+	message.Numbered = true
+	message.Number = a.next
+	a.next += a.step
+}
+
+var noteRegex = regexp.MustCompile(`(?i)^note\s+(left\s+of|right\s+of|over)\s+([^:]+?)\s*:\s*(.*)$`)
+
+func parseNote(line string) *Note {
+	matches := noteRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil
+	}
+
+	note := &Note{Text: strings.TrimSpace(matches[3])}
+	switch strings.ToLower(strings.Join(strings.Fields(matches[1]), " ")) {
+	case "left of":
+		note.Placement = NoteLeftOf
+	case "right of":
+		note.Placement = NoteRightOf
+	default:
+		note.Placement = NoteOver
+	}
+	for _, name := range strings.Split(matches[2], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			note.Participants = append(note.Participants, name)
+		}
+	}
+	if len(note.Participants) == 0 {
+		return nil
+	}
+	return note
+}
+
 func ensureParticipantsExist(diagram *SequenceDiagram, participantMap map[string]bool, participants ...string) {
 	for _, p := range participants {
 		if !participantMap[p] {
@@ -315,86 +773,269 @@ func ensureParticipantsExist(diagram *SequenceDiagram, participantMap map[string
 	}
 }
 
-func parseActivation(line string) {
-	activateRegex := regexp.MustCompile(`^\s*activate\s+(\w+)`)
-	deactivateRegex := regexp.MustCompile(`^\s*deactivate\s+(\w+)`)
-	
-	if activateRegex.MatchString(line) {
-		// Handle activation - for now, skip
-		return
+var blockRegex = regexp.MustCompile(`^(loop|alt|else|opt|par|and|critical|option|break|rect|end)(?:\s+(.*))?$`)
+
+var blockKinds = map[string]BlockKind{
+	"loop":     LoopBlock,
+	"alt":      AltBlock,
+	"opt":      OptBlock,
+	"par":      ParBlock,
+	"critical": CriticalBlock,
+	"break":    BreakBlock,
+	"rect":     RectBlock,
+}
+
+// sectionKinds maps each section keyword to the block it may divide.
+var sectionKinds = map[string]BlockKind{
+	"else":   AltBlock,
+	"and":    ParBlock,
+	"option": CriticalBlock,
+}
+
+// blockTracker builds the block tree and adds a timeline row for every
+// block line, which leaves room for frame headers and separators.
+type blockTracker struct {
+	diagram *SequenceDiagram
+	open    []int // indices into diagram.Blocks, innermost last
+	lines   []int // line numbers of the open blocks
+}
+
+func (t *blockTracker) handle(keyword, label string, lineNumber int) error {
+	row := len(t.diagram.Events)
+	label = strings.TrimSpace(label)
+
+	if keyword == "end" {
+		if len(t.open) == 0 {
+			return fmt.Errorf("end without an open block")
+		}
+		index := t.open[len(t.open)-1]
+		t.open = t.open[:len(t.open)-1]
+		t.lines = t.lines[:len(t.lines)-1]
+		t.diagram.Blocks[index].End = row
+		t.diagram.Events = append(t.diagram.Events, SequenceEvent{Kind: BlockEndEvent, Index: index})
+		return nil
 	}
-	
-	if deactivateRegex.MatchString(line) {
-		// Handle deactivation - for now, skip
-		return
+
+	if kind, isSection := sectionKinds[keyword]; isSection {
+		if len(t.open) == 0 || t.diagram.Blocks[t.open[len(t.open)-1]].Kind != kind {
+			return fmt.Errorf("%s outside of its block", keyword)
+		}
+		index := t.open[len(t.open)-1]
+		block := &t.diagram.Blocks[index]
+		block.Sections = append(block.Sections, BlockSection{Label: label, Start: row})
+		t.diagram.Events = append(t.diagram.Events, SequenceEvent{Kind: BlockSectionEvent, Index: index})
+		return nil
+	}
+
+	parent := -1
+	if len(t.open) > 0 {
+		parent = t.open[len(t.open)-1]
+	}
+	block := Block{
+		Kind:   blockKinds[keyword],
+		Parent: parent,
+		Start:  row,
+		End:    -1,
 	}
+	// A rect's label is its color
+	if block.Kind == RectBlock {
+		block.Color, label, _ = parseLeadingColor(label)
+	}
+	block.Sections = []BlockSection{{Label: label, Start: row}}
+	index := len(t.diagram.Blocks)
+	t.diagram.Blocks = append(t.diagram.Blocks, block)
+	t.open = append(t.open, index)
+	t.lines = append(t.lines, lineNumber)
+	t.diagram.Events = append(t.diagram.Events, SequenceEvent{Kind: BlockStartEvent, Index: index})
+	return nil
 }
 
-func parseRelationshipSymbol(symbol string) (RelationshipType, string, string) {
-	// Simplified relationship parsing
-	// ||--|| : one to one
-	// ||--o{ : one to many
-	// }o--|| : many to one
-	// }o--o{ : many to many
-	
-	fromCard := "1"
-	toCard := "1"
-	relType := OneToOne
-	
-	// Look for curly braces indicating "many"
-	if strings.Contains(symbol, "{") {
-		toCard = "M"
+// checkClosed reports the innermost block left open at the end of input.
+func (t *blockTracker) checkClosed() error {
+	if len(t.open) == 0 {
+		return nil
 	}
-	if strings.Contains(symbol, "}") {
-		fromCard = "M"
+	return fmt.Errorf("line %d: block is never closed with end", t.lines[len(t.lines)-1])
+}
+
+var activationRegex = regexp.MustCompile(`^\s*(activate|deactivate)\s+(\w+)`)
+
+// parseActivation parses "activate X" and "deactivate X" lines and reports
+// the participant and whether it is being activated.
+func parseActivation(line string) (string, bool, bool) {
+	matches := activationRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return "", false, false
 	}
-	
-	// Determine relationship type based on cardinalities
-	if fromCard == "1" && toCard == "1" {
-		relType = OneToOne
-	} else if fromCard == "1" && toCard == "M" {
-		relType = OneToMany
-	} else if fromCard == "M" && toCard == "1" {
-		relType = ManyToOne
-	} else if fromCard == "M" && toCard == "M" {
-		relType = ManyToMany
+	return matches[2], matches[1] == "activate", true
+}
+
+// activationTracker keeps the stack of open activation bars per participant.
+type activationTracker struct {
+	diagram *SequenceDiagram
+	open    map[string][]int // indices into diagram.Activations, innermost last
+}
+
+func (t *activationTracker) activate(participant string, row int) {
+	t.open[participant] = append(t.open[participant], len(t.diagram.Activations))
+	t.diagram.Activations = append(t.diagram.Activations, Activation{
+		Participant: participant,
+		Start:       row,
+		End:         -1,
+		Level:       len(t.open[participant]) - 1,
+	})
+}
+
+func (t *activationTracker) deactivate(participant string, row int) error {
+	stack := t.open[participant]
+	if len(stack) == 0 {
+		return fmt.Errorf("cannot deactivate %s: it is not active", participant)
+	}
+	t.diagram.Activations[stack[len(stack)-1]].End = row
+	t.open[participant] = stack[:len(stack)-1]
+	return nil
+}
+
+// closeAll ends the bars still open at the end of the diagram on the last row.
+func (t *activationTracker) closeAll(lastRow int) {
+	for i := range t.diagram.Activations {
+		activation := &t.diagram.Activations[i]
+		if activation.End < 0 {
+			activation.End = max(lastRow, activation.Start)
+		}
 	}
-	
-	return relType, fromCard, toCard
 }
 
 func ParseSequenceDiagram(input string) (*SequenceDiagram, error) {
 	diagram := &SequenceDiagram{
 		Participants: make([]Participant, 0),
 		Messages:     make([]Message, 0),
+		Notes:        make([]Note, 0),
+		Events:       make([]SequenceEvent, 0),
+		Activations:  make([]Activation, 0),
+		Blocks:       make([]Block, 0),
+		Boxes:        make([]Box, 0),
 	}
 	
 	scanner := bufio.NewScanner(strings.NewReader(input))
 	participantMap := make(map[string]bool)
+	activations := &activationTracker{diagram: diagram, open: make(map[string][]int)}
+	blocks := &blockTracker{diagram: diagram}
+	numbering := &autonumber{}
+	lifecycles := &lifecycleTracker{diagram: diagram, creating: make(map[string]bool), destroying: make(map[string]bool)}
+	openBox, boxLine := -1, 0
+	lineNumber := 0
 	
 	for scanner.Scan() {
+		lineNumber++
 		line := strings.TrimSpace(scanner.Text())
 		
 		if shouldSkipSequenceLine(line) {
 			continue
 		}
 		
+		// Boxes only hold participant declarations, so "end" inside one
+		// always closes it
+		if box := parseBox(line); box != nil {
+			if openBox >= 0 {
+				return diagram, fmt.Errorf("line %d: boxes cannot be nested", lineNumber)
+			}
+			openBox, boxLine = len(diagram.Boxes), lineNumber
+			diagram.Boxes = append(diagram.Boxes, *box)
+			continue
+		}
+		if line == "end" && openBox >= 0 {
+			openBox = -1
+			continue
+		}
+		
+		created := false
+		if matches := createRegex.FindStringSubmatch(line); matches != nil {
+			line, created = matches[1], true
+		}
+		
 		if participant := parseParticipant(line); participant != nil {
+			if created {
+				if participantMap[participant.Name] {
+					return diagram, fmt.Errorf("line %d: cannot create %s: it already exists", lineNumber, participant.Name)
+				}
+				participant.Created = true
+				lifecycles.creating[participant.Name] = true
+			}
 			if !participantMap[participant.Name] {
 				diagram.Participants = append(diagram.Participants, *participant)
 				participantMap[participant.Name] = true
 			}
+			if openBox >= 0 && !inAnyBox(diagram.Boxes, participant.Name) {
+				diagram.Boxes[openBox].Participants = append(diagram.Boxes[openBox].Participants, participant.Name)
+			}
+			continue
+		}
+		
+		if matches := destroyRegex.FindStringSubmatch(line); matches != nil {
+			ensureParticipantsExist(diagram, participantMap, matches[1])
+			lifecycles.destroying[matches[1]] = true
+			continue
+		}
+		
+		if matches := autonumberRegex.FindStringSubmatch(line); matches != nil {
+			numbering.configure(matches)
 			continue
 		}
 		
 		if message := parseMessage(line); message != nil {
 			ensureParticipantsExist(diagram, participantMap, message.From, message.To)
+			numbering.apply(message)
+			row := len(diagram.Events)
+			diagram.Events = append(diagram.Events, SequenceEvent{Kind: MessageEvent, Index: len(diagram.Messages)})
 			diagram.Messages = append(diagram.Messages, *message)
+			lifecycles.apply(*message, row)
+			
+			// "A->>+B" activates the receiver, "B-->>-A" deactivates the sender
+			if message.Activate {
+				activations.activate(message.To, row)
+			}
+			if message.Deactivate {
+				if err := activations.deactivate(message.From, row); err != nil {
+					return diagram, fmt.Errorf("line %d: %w", lineNumber, err)
+				}
+			}
 			continue
 		}
 		
-		parseActivation(line)
+		if note := parseNote(line); note != nil {
+			ensureParticipantsExist(diagram, participantMap, note.Participants...)
+			diagram.Events = append(diagram.Events, SequenceEvent{Kind: NoteEvent, Index: len(diagram.Notes)})
+			diagram.Notes = append(diagram.Notes, *note)
+			continue
+		}
+		
+		if matches := blockRegex.FindStringSubmatch(line); matches != nil {
+			if err := blocks.handle(matches[1], matches[2], lineNumber); err != nil {
+				return diagram, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			continue
+		}
+		
+		// Activation lines apply to the row of the preceding message
+		if name, activate, ok := parseActivation(line); ok {
+			ensureParticipantsExist(diagram, participantMap, name)
+			row := max(len(diagram.Events)-1, 0)
+			if activate {
+				activations.activate(name, row)
+			} else if err := activations.deactivate(name, row); err != nil {
+				return diagram, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+		}
 	}
 	
+	activations.closeAll(len(diagram.Events) - 1)
+	lifecycles.finish(len(diagram.Events) - 1)
+	if openBox >= 0 {
+		return diagram, fmt.Errorf("line %d: box is never closed with end", boxLine)
+	}
+	if err := blocks.checkClosed(); err != nil {
+		return diagram, err
+	}
 	return diagram, scanner.Err()
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package mermaid
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -67,10 +68,16 @@ func TestParseSequenceDiagram(t *testing.T) {
 
 func TestMessageTypes(t *testing.T) {
 	input := `sequenceDiagram
-    A->B: solid arrow
-    A-->B: dashed arrow
-    A->>B: solid arrow with x
-    A-->>B: dashed arrow with x`
+    A->B: solid line
+    A-->B: dashed line
+    A->>B: solid arrow
+    A-->>B: dashed arrow
+    A-xB: solid cross
+    A--xB: dashed cross
+    A-)B: solid async
+    A--)B: dashed async
+    A<<->>B: solid bidirectional
+    A<<-->>B: dashed bidirectional`
 	
 	diagram, err := ParseSequenceDiagram(input)
 	if err != nil {
@@ -78,10 +85,16 @@ func TestMessageTypes(t *testing.T) {
 	}
 	
 	expectedTypes := []MessageType{
+		SolidLine,
+		DashedLine,
 		SolidArrow,
 		DashedArrow,
-		SolidArrowWithX,
-		DashedArrowWithX,
+		SolidCross,
+		DashedCross,
+		SolidAsync,
+		DashedAsync,
+		SolidBidirectional,
+		DashedBidirectional,
 	}
 	
 	if len(diagram.Messages) != len(expectedTypes) {
@@ -92,6 +105,9 @@ func TestMessageTypes(t *testing.T) {
 		if diagram.Messages[i].Type != expectedType {
 			t.Errorf("Message %d: expected type %v, got %v", i, expectedType, diagram.Messages[i].Type)
 		}
+		if diagram.Messages[i].From != "A" || diagram.Messages[i].To != "B" {
+			t.Errorf("Message %d: expected A to B, got %s to %s", i, diagram.Messages[i].From, diagram.Messages[i].To)
+		}
 	}
 }
 
@@ -222,7 +238,6 @@ func TestParseERDiagramComplex(t *testing.T) {
 }
 
 func TestParseActivation(t *testing.T) {
-	// Test activation/deactivation parsing (currently skipped)
 	input := `sequenceDiagram
     A->B: Hello
     activate B
@@ -238,14 +253,19 @@ func TestParseActivation(t *testing.T) {
 	if len(diagram.Messages) != 2 {
 		t.Errorf("Expected 2 messages, got %d", len(diagram.Messages))
 	}
+	
+	expected := Activation{Participant: "B", Start: 0, End: 1, Level: 0}
+	if len(diagram.Activations) != 1 || diagram.Activations[0] != expected {
+		t.Errorf("Expected activation %+v, got %+v", expected, diagram.Activations)
+	}
 }
 
 func TestParseMessageDefaultType(t *testing.T) {
 	input := `sequenceDiagram
     A->B: solid
     A-->B: dashed
-    A->>B: solid_x
-    A-->>B: dashed_x
+    A->>B: solid_arrow
+    A-->>B: dashed_arrow
     A-?B: unknown`
 
 	diagram, err := ParseSequenceDiagram(input)
@@ -254,11 +274,11 @@ func TestParseMessageDefaultType(t *testing.T) {
 	}
 
 	expectedTypes := []MessageType{
+		SolidLine,
+		DashedLine,
 		SolidArrow,
 		DashedArrow,
-		SolidArrowWithX,
-		DashedArrowWithX,
-		SolidArrow, // unknown type defaults to SolidArrow
+		SolidLine, // unknown type defaults to SolidLine
 	}
 
 	for i, expectedType := range expectedTypes {
@@ -309,9 +329,12 @@ func TestParseRelationshipSymbolEdgeCases(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			relType, _, _ := parseRelationshipSymbol(tt.symbol)
-			if relType != tt.expectedType {
-				t.Errorf("Expected %v, got %v", tt.expectedType, relType)
+			rel := parseRelationship("A " + tt.symbol + " B : test")
+			if rel == nil {
+				t.Fatalf("Expected %q to parse as a relationship", tt.symbol)
+			}
+			if rel.Type != tt.expectedType {
+				t.Errorf("Expected %v, got %v", tt.expectedType, rel.Type)
 			}
 		})
 	}
@@ -378,8 +401,8 @@ func TestParseRelationshipInvalidCases(t *testing.T) {
 func TestGetMessageTypeDefault(t *testing.T) {
 	// Test default case in getMessageType
 	msgType := getMessageType("unknown-arrow")
-	if msgType != SolidArrow {
-		t.Errorf("Expected SolidArrow for unknown type, got %v", msgType)
+	if msgType != SolidLine {
+		t.Errorf("Expected SolidLine for unknown type, got %v", msgType)
 	}
 }
 
@@ -422,4 +445,420 @@ func TestParseSequenceDiagramActivationPaths(t *testing.T) {
 	if len(diagram.Messages) != 1 {
 		t.Errorf("Expected 1 message, got %d", len(diagram.Messages))
 	}
-}
\ No newline at end of file
+}
+
+func TestParseSequenceNotes(t *testing.T) {
+	input := `sequenceDiagram
+    participant A
+    participant B
+    Note left of A: before
+    A->B: Hello
+    note right of B: after
+    Note over A,B: spanning
+    Note over C: new participant`
+
+	diagram, err := ParseSequenceDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []Note{
+		{Placement: NoteLeftOf, Participants: []string{"A"}, Text: "before"},
+		{Placement: NoteRightOf, Participants: []string{"B"}, Text: "after"},
+		{Placement: NoteOver, Participants: []string{"A", "B"}, Text: "spanning"},
+		{Placement: NoteOver, Participants: []string{"C"}, Text: "new participant"},
+	}
+	if len(diagram.Notes) != len(expected) {
+		t.Fatalf("Expected %d notes, got %d", len(expected), len(diagram.Notes))
+	}
+	for i, want := range expected {
+		got := diagram.Notes[i]
+		if got.Placement != want.Placement || got.Text != want.Text || strings.Join(got.Participants, ",") != strings.Join(want.Participants, ",") {
+			t.Errorf("Note %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	if len(diagram.Participants) != 3 {
+		t.Errorf("Expected 3 participants, got %d", len(diagram.Participants))
+	}
+
+	// The message sits between the first and second note
+	expectedEvents := []SequenceEvent{
+		{Kind: NoteEvent, Index: 0},
+		{Kind: MessageEvent, Index: 0},
+		{Kind: NoteEvent, Index: 1},
+		{Kind: NoteEvent, Index: 2},
+		{Kind: NoteEvent, Index: 3},
+	}
+	if len(diagram.Events) != len(expectedEvents) {
+		t.Fatalf("Expected %d events, got %d", len(expectedEvents), len(diagram.Events))
+	}
+	for i, want := range expectedEvents {
+		if diagram.Events[i] != want {
+			t.Errorf("Event %d: expected %+v, got %+v", i, want, diagram.Events[i])
+		}
+	}
+}
+
+func TestParseActivationShorthand(t *testing.T) {
+	input := `sequenceDiagram
+    Alice->>+John: Hello John
+    Alice->>+John: Can you hear me?
+    John-->>-Alice: Yes
+    John-->>-Alice: I feel great
+    Alice->>+Bob: Open ended`
+
+	diagram, err := ParseSequenceDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !diagram.Messages[0].Activate || !diagram.Messages[2].Deactivate {
+		t.Errorf("Expected +/- suffixes to be recorded on messages")
+	}
+	if diagram.Messages[0].To != "John" {
+		t.Errorf("Expected target John, got %q", diagram.Messages[0].To)
+	}
+
+	expected := []Activation{
+		{Participant: "John", Start: 0, End: 3, Level: 0},
+		{Participant: "John", Start: 1, End: 2, Level: 1},
+		{Participant: "Bob", Start: 4, End: 4, Level: 0},
+	}
+	if len(diagram.Activations) != len(expected) {
+		t.Fatalf("Expected %d activations, got %d", len(expected), len(diagram.Activations))
+	}
+	for i, want := range expected {
+		if diagram.Activations[i] != want {
+			t.Errorf("Activation %d: expected %+v, got %+v", i, want, diagram.Activations[i])
+		}
+	}
+}
+
+func TestParseUnbalancedDeactivation(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "deactivate line",
+			input: "sequenceDiagram\n    A->B: Hello\n    deactivate B",
+		},
+		{
+			name:  "minus suffix",
+			input: "sequenceDiagram\n    A->>+B: Hello\n    B-->>-A: Hi\n    B-->>-A: Again",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSequenceDiagram(tt.input)
+			if err == nil || !strings.Contains(err.Error(), "cannot deactivate B") {
+				t.Errorf("Expected unbalanced deactivation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseSequenceBlocks(t *testing.T) {
+	input := `sequenceDiagram
+    loop Every minute
+        A->>B: Ping
+        alt is healthy
+            B-->>A: Pong
+        else is sick
+            B-->>A: Help
+        end
+    end
+    par Alice to Bob
+        A->>B: One
+    and Alice to Carol
+        A->>C: Two
+    end
+    critical Connect
+        A->>B: Connect
+    option Timeout
+        A->>A: Retry
+    end
+    opt
+        A->>B: Maybe
+    end
+    break when done
+        A->>B: Stop
+    end`
+
+	diagram, err := ParseSequenceDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(diagram.Blocks) != 6 {
+		t.Fatalf("Expected 6 blocks, got %d", len(diagram.Blocks))
+	}
+
+	tests := []struct {
+		kind     BlockKind
+		parent   int
+		sections []string
+		start    int
+		end      int
+	}{
+		{LoopBlock, -1, []string{"Every minute"}, 0, 7},
+		{AltBlock, 0, []string{"is healthy", "is sick"}, 2, 6},
+		{ParBlock, -1, []string{"Alice to Bob", "Alice to Carol"}, 8, 12},
+		{CriticalBlock, -1, []string{"Connect", "Timeout"}, 13, 17},
+		{OptBlock, -1, []string{""}, 18, 20},
+		{BreakBlock, -1, []string{"when done"}, 21, 23},
+	}
+	for i, tt := range tests {
+		block := diagram.Blocks[i]
+		if block.Kind != tt.kind || block.Parent != tt.parent || block.Start != tt.start || block.End != tt.end {
+			t.Errorf("Block %d: expected kind %v parent %d rows %d-%d, got %+v", i, tt.kind, tt.parent, tt.start, tt.end, block)
+		}
+		labels := make([]string, 0, len(block.Sections))
+		for _, section := range block.Sections {
+			labels = append(labels, section.Label)
+		}
+		if strings.Join(labels, "|") != strings.Join(tt.sections, "|") {
+			t.Errorf("Block %d: expected sections %v, got %v", i, tt.sections, labels)
+		}
+	}
+
+	// The else section starts on its own row
+	if diagram.Blocks[1].Sections[1].Start != 4 || diagram.Events[4].Kind != BlockSectionEvent {
+		t.Errorf("Expected else section at row 4, got %+v", diagram.Blocks[1].Sections[1])
+	}
+	if len(diagram.Messages) != 9 {
+		t.Errorf("Expected 9 messages, got %d", len(diagram.Messages))
+	}
+}
+
+func TestParseSequenceBlockErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"stray end", "sequenceDiagram\n    A->B: Hello\n    end", "line 3: end without an open block"},
+		{"else outside alt", "sequenceDiagram\n    loop\n    else\n    end", "line 3: else outside of its block"},
+		{"unclosed", "sequenceDiagram\n    loop forever\n    A->B: Hello", "line 2: block is never closed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSequenceDiagram(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParseSelfMessage(t *testing.T) {
+	input := `sequenceDiagram
+    A->>+A: recurse
+    A-->>-A: unwind`
+
+	diagram, err := ParseSequenceDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(diagram.Participants) != 1 {
+		t.Errorf("Expected 1 participant, got %d", len(diagram.Participants))
+	}
+	if diagram.Messages[0].From != "A" || diagram.Messages[0].To != "A" {
+		t.Errorf("Expected self-message, got %+v", diagram.Messages[0])
+	}
+
+	expected := Activation{Participant: "A", Start: 0, End: 1, Level: 0}
+	if len(diagram.Activations) != 1 || diagram.Activations[0] != expected {
+		t.Errorf("Expected activation %+v, got %+v", expected, diagram.Activations)
+	}
+}
+
+func TestParseParticipantKinds(t *testing.T) {
+	input := `sequenceDiagram
+    actor Customer as End User
+    participant Web@{ "type": "boundary" }
+    participant Api@{ "type" : "control" } as API Gateway
+    participant Order@{ "type": "entity" }
+    participant Db@{ "type": "database" }
+    participant Cache@{ "type": "collections" }
+    participant Jobs@{ "type": "queue" }
+    participant Plain
+    Customer->>Web: Browse`
+
+	diagram, err := ParseSequenceDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []Participant{
+		{Name: "Customer", Alias: "End User", Kind: ActorParticipant},
+		{Name: "Web", Alias: "Web", Kind: BoundaryParticipant},
+		{Name: "Api", Alias: "API Gateway", Kind: ControlParticipant},
+		{Name: "Order", Alias: "Order", Kind: EntityParticipant},
+		{Name: "Db", Alias: "Db", Kind: DatabaseParticipant},
+		{Name: "Cache", Alias: "Cache", Kind: CollectionsParticipant},
+		{Name: "Jobs", Alias: "Jobs", Kind: QueueParticipant},
+		{Name: "Plain", Alias: "Plain", Kind: ParticipantBox},
+	}
+	if len(diagram.Participants) != len(expected) {
+		t.Fatalf("Expected %d participants, got %d", len(expected), len(diagram.Participants))
+	}
+	for i, want := range expected {
+		if diagram.Participants[i] != want {
+			t.Errorf("Participant %d: expected %+v, got %+v", i, want, diagram.Participants[i])
+		}
+	}
+}
+
+func TestParseAutonumber(t *testing.T) {
+	input := `sequenceDiagram
+    A->>B: unnumbered
+    autonumber
+    A->>B: one
+    B-->>A: two
+    autonumber 10 5
+    A->>B: ten
+    A->>B: fifteen
+    autonumber off
+    A->>B: plain
+    autonumber 3
+    A->>B: three`
+
+	diagram, err := ParseSequenceDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		numbered bool
+		number   int
+	}{
+		{false, 0},
+		{true, 1},
+		{true, 2},
+		{true, 10},
+		{true, 15},
+		{false, 0},
+		{true, 3},
+	}
+	if len(diagram.Messages) != len(expected) {
+		t.Fatalf("Expected %d messages, got %d", len(expected), len(diagram.Messages))
+	}
+	for i, want := range expected {
+		message := diagram.Messages[i]
+		if message.Numbered != want.numbered || message.Number != want.number {
+			t.Errorf("Message %d: expected numbered=%v number=%d, got numbered=%v number=%d", i, want.numbered, want.number, message.Numbered, message.Number)
+		}
+	}
+}
+
+func TestParseSequenceBoxes(t *testing.T) {
+	input := `sequenceDiagram
+    box Aqua Backend
+        participant Api
+        actor Ops
+    end
+    box Frontend only
+        participant Web
+    end
+    participant Db
+    Web->>Api: call
+    Api->>Db: query`
+
+	diagram, err := ParseSequenceDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(diagram.Boxes) != 2 {
+		t.Fatalf("Expected 2 boxes, got %d", len(diagram.Boxes))
+	}
+	backend := diagram.Boxes[0]
+	if backend.Title != "Backend" || backend.Color.Hex != "#00FFFF" || strings.Join(backend.Participants, ",") != "Api,Ops" {
+		t.Errorf("Unexpected backend box: %+v", backend)
+	}
+	frontend := diagram.Boxes[1]
+	if frontend.Title != "Frontend only" || frontend.Color.Hex != "" || strings.Join(frontend.Participants, ",") != "Web" {
+		t.Errorf("Unexpected frontend box: %+v", frontend)
+	}
+	if len(diagram.Participants) != 4 {
+		t.Errorf("Expected 4 participants, got %d", len(diagram.Participants))
+	}
+
+	if _, err := ParseSequenceDiagram("sequenceDiagram\n    box Aqua\n    participant A"); err == nil || !strings.Contains(err.Error(), "line 2: box is never closed") {
+		t.Errorf("Expected unclosed box error, got %v", err)
+	}
+}
+
+func TestParseCreateAndDestroy(t *testing.T) {
+	input := `sequenceDiagram
+    participant Alice
+    Alice->>Bob: Hi
+    create participant Carl
+    Alice->>Carl: Hi Carl!
+    create actor D as Donald
+    Carl->>D: Hi!
+    destroy Carl
+    Alice-xCarl: We are too many`
+
+	diagram, err := ParseSequenceDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	participants := make(map[string]Participant)
+	for _, participant := range diagram.Participants {
+		participants[participant.Name] = participant
+	}
+
+	if alice := participants["Alice"]; alice.Created || alice.Destroyed {
+		t.Errorf("Alice should live for the whole diagram: %+v", alice)
+	}
+	carl := participants["Carl"]
+	if !carl.Created || carl.CreatedAt != 1 || !carl.Destroyed || carl.DestroyedAt != 3 {
+		t.Errorf("Carl should be created at row 1 and destroyed at row 3: %+v", carl)
+	}
+	donald := participants["D"]
+	if !donald.Created || donald.CreatedAt != 2 || donald.Kind != ActorParticipant || donald.Alias != "Donald" {
+		t.Errorf("Unexpected created actor: %+v", donald)
+	}
+
+	if _, err := ParseSequenceDiagram("sequenceDiagram\n    A->>B: Hi\n    create participant B"); err == nil || !strings.Contains(err.Error(), "line 3: cannot create B") {
+		t.Errorf("Expected error for creating an existing participant, got %v", err)
+	}
+}
+
+func TestParseRectBlocks(t *testing.T) {
+	input := `sequenceDiagram
+    rect rgba(0, 0, 255, .1)
+        A->>B: hi
+        rect Pink
+            loop Every minute
+                B->>C: ping
+            end
+        end
+    end`
+
+	diagram, err := ParseSequenceDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(diagram.Blocks) != 3 {
+		t.Fatalf("Expected 3 blocks, got %d", len(diagram.Blocks))
+	}
+	outer, inner, loop := diagram.Blocks[0], diagram.Blocks[1], diagram.Blocks[2]
+	if outer.Kind != RectBlock || outer.Color != (Color{Hex: "#0000FF", Opacity: 10}) || outer.Start != 0 || outer.End != 7 {
+		t.Errorf("Unexpected outer rect: %+v", outer)
+	}
+	if inner.Kind != RectBlock || inner.Color.Hex != "#FFC0CB" || inner.Parent != 0 || inner.Sections[0].Label != "" {
+		t.Errorf("Unexpected inner rect: %+v", inner)
+	}
+	if loop.Kind != LoopBlock || loop.Parent != 1 {
+		t.Errorf("Loop should be nested in the inner rect: %+v", loop)
+	}
+}
@@ -0,0 +1,80 @@
+package mermaid
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type PieDiagram struct {
+	Title    string
+	ShowData bool // legend shows the raw value next to each label
+	Slices   []PieSlice
+}
+
+func (pd *PieDiagram) GetType() DiagramType {
+	return PieDiagramType
+}
+
+type PieSlice struct {
+	Label string
+	Value float64
+}
+
+var (
+	pieHeaderRegex  = regexp.MustCompile(`^pie(?:\s+(showData))?(?:\s+title\s+(.*))?$`)
+	pieTitleRegex   = regexp.MustCompile(`^title\s+(.*)$`)
+	pieSliceRegex   = regexp.MustCompile(`^"([^"]*)"\s*:\s*(\S+)$`)
+	pieIgnoredRegex = regexp.MustCompile(`^(accTitle|accDescr)\b`)
+)
+
+func ParsePieDiagram(input string) (*PieDiagram, error) {
+	diagram := &PieDiagram{
+		Slices: make([]PieSlice, 0),
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "%%") || pieIgnoredRegex.MatchString(line) {
+			continue
+		}
+
+		if header := pieHeaderRegex.FindStringSubmatch(line); header != nil {
+			diagram.ShowData = diagram.ShowData || header[1] != ""
+			if header[2] != "" {
+				diagram.Title = strings.TrimSpace(header[2])
+			}
+			continue
+		}
+
+		if line == "showData" {
+			diagram.ShowData = true
+			continue
+		}
+
+		if title := pieTitleRegex.FindStringSubmatch(line); title != nil {
+			diagram.Title = strings.TrimSpace(title[1])
+			continue
+		}
+
+		if slice := pieSliceRegex.FindStringSubmatch(line); slice != nil {
+			value, err := strconv.ParseFloat(slice[2], 64)
+			if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+				return diagram, fmt.Errorf("line %d: invalid value %q for %s", lineNumber, slice[2], slice[1])
+			}
+			if value < 0 {
+				return diagram, fmt.Errorf("line %d: value for %s must not be negative", lineNumber, slice[1])
+			}
+			diagram.Slices = append(diagram.Slices, PieSlice{Label: slice[1], Value: value})
+		}
+	}
+
+	return diagram, scanner.Err()
+}
@@ -0,0 +1,77 @@
+package mermaid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePieDiagram(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		title    string
+		showData bool
+		slices   []PieSlice
+	}{
+		{
+			name:   "title on its own line",
+			input:  "pie\n    title Key elements in Product X\n    \"Calcium\" : 42.96\n    \"Potassium\" : 50.05",
+			title:  "Key elements in Product X",
+			slices: []PieSlice{{"Calcium", 42.96}, {"Potassium", 50.05}},
+		},
+		{
+			name:     "showData and title in the header",
+			input:    "%% pets\npie showData title Pets adopted\n    \"Dogs\" : 386\n    \"Cats: indoor\":85",
+			title:    "Pets adopted",
+			showData: true,
+			slices:   []PieSlice{{"Dogs", 386}, {"Cats: indoor", 85}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseDiagram(tt.input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			diagram, ok := parsed.(*PieDiagram)
+			if !ok {
+				t.Fatalf("Expected a pie diagram, got %T", parsed)
+			}
+			if diagram.Title != tt.title || diagram.ShowData != tt.showData {
+				t.Errorf("Expected title %q showData %v, got %q %v", tt.title, tt.showData, diagram.Title, diagram.ShowData)
+			}
+			if len(diagram.Slices) != len(tt.slices) {
+				t.Fatalf("Expected %d slices, got %d", len(tt.slices), len(diagram.Slices))
+			}
+			for i, want := range tt.slices {
+				if diagram.Slices[i] != want {
+					t.Errorf("Slice %d: expected %+v, got %+v", i, want, diagram.Slices[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParsePieDiagramErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"negative value", "pie\n    \"Loss\" : -5", "line 2: value for Loss must not be negative"},
+		{"invalid value", "pie\n    \"A\" : ten", `line 2: invalid value "ten" for A`},
+		{"not a number", "pie\n    \"A\" : 1\n    \"B\" : NaN", `line 3: invalid value "NaN" for B`},
+		{"infinite", "pie\n    \"A\" : Inf", `line 2: invalid value "Inf" for A`},
+		{"negative infinite", "pie\n    \"A\" : -Inf", `line 2: invalid value "-Inf" for A`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePieDiagram(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package mermaid
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type StateDiagram struct {
+	Direction   string
+	States      []State
+	Transitions []StateTransition
+	Notes       []StateNote
+}
+
+func (sd *StateDiagram) GetType() DiagramType {
+	return StateDiagramType
+}
+
+// State is a node of a state diagram. States nested in a composite state
+// name it as Parent; Region numbers the concurrent region ("--" separated)
+// of the parent they belong to. A composite state's Direction is set by a
+// direction statement inside its block and is empty otherwise.
+type State struct {
+	ID        string
+	Label     string
+	Kind      StateKind
+	Parent    string
+	Region    int
+	Direction string
+}
+
+type StateKind int
+
+const (
+	SimpleState StateKind = iota
+	StartState
+	EndState
+	CompositeState
+	ChoiceState
+	ForkState
+	JoinState
+)
+
+type StateTransition struct {
+	From  string
+	To    string
+	Label string
+}
+
+type StateNote struct {
+	State    string
+	Position string // "left" or "right"
+	Text     string
+}
+
+var (
+	stateDiagramHeaderRegex = regexp.MustCompile(`^stateDiagram(?:-v2)?\b`)
+	stateTransitionRegex    = regexp.MustCompile(`^(\[\*\]|[\w.]+)(?::::\w+)?\s*-->\s*(\[\*\]|[\w.]+)(?::::\w+)?\s*(?::\s*(.*))?$`)
+	stateDeclarationRegex   = regexp.MustCompile(`^state\s+(?:"([^"]*)"\s+as\s+)?([\w.]+)(?:\s*<<(\w+)>>)?(?::::\w+)?\s*(\{)?$`)
+	stateDescriptionRegex   = regexp.MustCompile(`^([\w.]+)\s*:\s*(.+)$`)
+	stateNoteRegex          = regexp.MustCompile(`^note\s+(left|right)\s+of\s+([\w.]+)\s*(?::\s*(.*))?$`)
+	stateIDRegex            = regexp.MustCompile(`^([\w.]+)(?::::\w+)?$`)
+)
+
+// stateScope is an open composite state block and its current region.
+type stateScope struct {
+	id     string
+	region int
+}
+
+type stateParser struct {
+	diagram    *StateDiagram
+	stateIndex map[string]int
+	scopes     []stateScope
+	note       *StateNote
+}
+
+func ParseStateDiagram(input string) (*StateDiagram, error) {
+	diagram := &StateDiagram{
+		Direction:   "TB",
+		States:      make([]State, 0),
+		Transitions: make([]StateTransition, 0),
+		Notes:       make([]StateNote, 0),
+	}
+	parser := &stateParser{
+		diagram:    diagram,
+		stateIndex: make(map[string]int),
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if parser.note != nil {
+			parser.collectNote(line)
+			continue
+		}
+
+		if line == "" || strings.HasPrefix(line, "%%") || stateDiagramHeaderRegex.MatchString(line) {
+			continue
+		}
+
+		parser.parseLine(line)
+	}
+
+	return diagram, scanner.Err()
+}
+
+func (p *stateParser) parseLine(line string) {
+	switch {
+	case line == "}":
+		if len(p.scopes) > 0 {
+			p.scopes = p.scopes[:len(p.scopes)-1]
+		}
+		return
+	case line == "--":
+		if len(p.scopes) > 0 {
+			p.scopes[len(p.scopes)-1].region++
+		}
+		return
+	case strings.HasPrefix(line, "direction "):
+		direction := normalizeDirection(strings.TrimSpace(strings.TrimPrefix(line, "direction ")))
+		if scope := p.currentScope(); scope.id != "" {
+			p.diagram.States[p.stateIndex[scope.id]].Direction = direction
+		} else {
+			p.diagram.Direction = direction
+		}
+		return
+	}
+
+	keyword := strings.Fields(line)[0]
+	if keyword == "classDef" || keyword == "class" || keyword == "style" || keyword == "click" {
+		return
+	}
+
+	if matches := stateTransitionRegex.FindStringSubmatch(line); matches != nil {
+		p.diagram.Transitions = append(p.diagram.Transitions, StateTransition{
+			From:  p.resolve(matches[1], StartState),
+			To:    p.resolve(matches[2], EndState),
+			Label: strings.TrimSpace(matches[3]),
+		})
+		return
+	}
+
+	if matches := stateNoteRegex.FindStringSubmatch(line); matches != nil {
+		note := StateNote{State: p.ensureState(matches[2]), Position: matches[1]}
+		if matches[3] != "" {
+			note.Text = strings.TrimSpace(matches[3])
+			p.diagram.Notes = append(p.diagram.Notes, note)
+		} else {
+			p.note = &note
+		}
+		return
+	}
+
+	if matches := stateDeclarationRegex.FindStringSubmatch(line); matches != nil {
+		p.declareState(matches[2], matches[1], matches[3], matches[4] != "")
+		return
+	}
+
+	if matches := stateDescriptionRegex.FindStringSubmatch(line); matches != nil {
+		id := p.ensureState(matches[1])
+		p.diagram.States[p.stateIndex[id]].Label = strings.TrimSpace(matches[2])
+		return
+	}
+
+	if matches := stateIDRegex.FindStringSubmatch(line); matches != nil {
+		p.ensureState(matches[1])
+	}
+}
+
+// collectNote gathers the lines of a multi-line note until "end note".
+func (p *stateParser) collectNote(line string) {
+	if line == "end note" {
+		p.diagram.Notes = append(p.diagram.Notes, *p.note)
+		p.note = nil
+		return
+	}
+	if p.note.Text != "" {
+		p.note.Text += "\n"
+	}
+	p.note.Text += line
+}
+
+func (p *stateParser) declareState(id, label, stereotype string, opensBlock bool) {
+	id = p.ensureState(id)
+	state := &p.diagram.States[p.stateIndex[id]]
+	if label != "" {
+		state.Label = label
+	}
+
+	switch strings.ToLower(stereotype) {
+	case "choice":
+		state.Kind = ChoiceState
+	case "fork":
+		state.Kind = ForkState
+	case "join":
+		state.Kind = JoinState
+	}
+
+	if opensBlock {
+		state.Kind = CompositeState
+		p.scopes = append(p.scopes, stateScope{id: id})
+	}
+}
+
+func (p *stateParser) currentScope() stateScope {
+	if len(p.scopes) == 0 {
+		return stateScope{}
+	}
+	return p.scopes[len(p.scopes)-1]
+}
+
+// resolve maps "[*]" onto the start or end pseudo-state of the current scope;
+// each composite state and region gets its own pair.
+func (p *stateParser) resolve(reference string, pseudoKind StateKind) string {
+	if reference != "[*]" {
+		return p.ensureState(reference)
+	}
+
+	scope := p.currentScope()
+	suffix := "[*]start"
+	if pseudoKind == EndState {
+		suffix = "[*]end"
+	}
+	id := scope.id + suffix
+	if scope.region > 0 {
+		id = fmt.Sprintf("%s#%d%s", scope.id, scope.region, suffix)
+	}
+
+	if _, exists := p.stateIndex[id]; !exists {
+		p.stateIndex[id] = len(p.diagram.States)
+		p.diagram.States = append(p.diagram.States, State{
+			ID:     id,
+			Kind:   pseudoKind,
+			Parent: scope.id,
+			Region: scope.region,
+		})
+	}
+	return id
+}
+
+// ensureState registers a state on first reference. A state belongs to the
+// first composite state it is referenced in.
+func (p *stateParser) ensureState(id string) string {
+	scope := p.currentScope()
+	if index, exists := p.stateIndex[id]; exists {
+		state := &p.diagram.States[index]
+		if state.Parent == "" && scope.id != "" && !p.isWithin(scope.id, id) {
+			state.Parent = scope.id
+			state.Region = scope.region
+		}
+		return id
+	}
+
+	p.stateIndex[id] = len(p.diagram.States)
+	p.diagram.States = append(p.diagram.States, State{
+		ID:     id,
+		Label:  id,
+		Kind:   SimpleState,
+		Parent: scope.id,
+		Region: scope.region,
+	})
+	return id
+}
+
+// isWithin reports whether id is ancestor or is nested inside ancestor.
+func (p *stateParser) isWithin(id, ancestor string) bool {
+	for id != "" {
+		if id == ancestor {
+			return true
+		}
+		id = p.diagram.States[p.stateIndex[id]].Parent
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package mermaid
+
+import (
+	"testing"
+)
+
+func TestParseStateDiagram(t *testing.T) {
+	input := `stateDiagram-v2
+    direction LR
+    [*] --> Still
+    Still --> Moving : push
+    Moving --> [*]
+    state "Waiting for input" as Waiting
+    state check <<choice>>
+    Moving --> check
+    note right of Still : idle
+    note left of Moving
+        first line
+        second line
+    end note`
+
+	diagram, err := ParseStateDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if diagram.Direction != "LR" {
+		t.Errorf("Expected direction LR, got %s", diagram.Direction)
+	}
+
+	kinds := make(map[string]StateKind)
+	labels := make(map[string]string)
+	for _, state := range diagram.States {
+		kinds[state.ID] = state.Kind
+		labels[state.ID] = state.Label
+	}
+	if kinds["[*]start"] != StartState || kinds["[*]end"] != EndState {
+		t.Errorf("Expected top-level start and end pseudo-states, got %v", kinds)
+	}
+	if kinds["check"] != ChoiceState {
+		t.Errorf("Expected check to be a choice state")
+	}
+	if labels["Waiting"] != "Waiting for input" {
+		t.Errorf("Expected Waiting label, got %q", labels["Waiting"])
+	}
+
+	if len(diagram.Transitions) != 4 {
+		t.Fatalf("Expected 4 transitions, got %d", len(diagram.Transitions))
+	}
+	if diagram.Transitions[1].Label != "push" {
+		t.Errorf("Expected transition label push, got %q", diagram.Transitions[1].Label)
+	}
+
+	if len(diagram.Notes) != 2 {
+		t.Fatalf("Expected 2 notes, got %d", len(diagram.Notes))
+	}
+	if diagram.Notes[1].Position != "left" || diagram.Notes[1].Text != "first line\nsecond line" {
+		t.Errorf("Unexpected multi-line note: %+v", diagram.Notes[1])
+	}
+}
+
+func TestParseCompositeAndConcurrentStates(t *testing.T) {
+	input := `stateDiagram-v2
+    [*] --> Active
+    state Active {
+        direction LR
+        [*] --> NumLockOff
+        NumLockOff --> NumLockOn
+        --
+        [*] --> CapsLockOff
+        CapsLockOff --> CapsLockOn
+    }
+    state fork_state <<fork>>
+    Active --> fork_state`
+
+	diagram, err := ParseStateDiagram(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	states := make(map[string]State)
+	for _, state := range diagram.States {
+		states[state.ID] = state
+	}
+
+	if states["Active"].Kind != CompositeState {
+		t.Errorf("Expected Active to be composite")
+	}
+	if states["Active"].Direction != "LR" || diagram.Direction != "TB" {
+		t.Errorf("Expected direction LR inside Active only, got %q and %q", states["Active"].Direction, diagram.Direction)
+	}
+	tests := []struct {
+		id     string
+		parent string
+		region int
+	}{
+		{"NumLockOff", "Active", 0},
+		{"CapsLockOn", "Active", 1},
+		{"Active[*]start", "Active", 0},
+		{"Active#1[*]start", "Active", 1},
+		{"fork_state", "", 0},
+	}
+	for _, tt := range tests {
+		state, ok := states[tt.id]
+		if !ok {
+			t.Errorf("State %s not found", tt.id)
+			continue
+		}
+		if state.Parent != tt.parent || state.Region != tt.region {
+			t.Errorf("State %s: expected parent %q region %d, got %q region %d", tt.id, tt.parent, tt.region, state.Parent, state.Region)
+		}
+	}
+	if states["fork_state"].Kind != ForkState {
+		t.Errorf("Expected fork_state to be a fork")
+	}
+}